@@ -4,18 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
-	"time"
 )
 
 func (c Client) GetBusinessAccessToken(ctx context.Context) (*GetAccessTokenResponse, error) {
-	timestamp := time.Now().Format(time.RFC3339)
-	signature, err := generateRSASignature(timestamp, c.Config.RsaPrivateKey, c.Config.ClientID)
-	if err != nil {
-		return nil, err
-	}
-
-	headers := c.BuildHeader(timestamp, signature, "", "", "")
-
 	body, err := json.Marshal(
 		GetBusinessAccessTokenRequest{
 			GrantType:      "client_credentials",
@@ -26,7 +17,14 @@ func (c Client) GetBusinessAccessToken(ctx context.Context) (*GetAccessTokenResp
 		return nil, err
 	}
 
-	resp, err := c.Execute(ctx, http.MethodPost, GetBusinessAccessTokenEndpoint, headers, body)
+	resp, err := c.send(ctx, &call{
+		operation: OperationGetBusinessAccessToken,
+		method:    http.MethodPost,
+		path:      GetBusinessAccessTokenEndpoint,
+		body:      body,
+		sign:      c.accessTokenHeaders(""),
+	})
+	c.observeTokenRefresh(ctx, TokenKindBusiness, err)
 	if err != nil {
 		return nil, err
 	}
@@ -41,7 +39,10 @@ func (c Client) GetBusinessAccessToken(ctx context.Context) (*GetAccessTokenResp
 }
 
 func (c Client) GetAuthCode(ctx context.Context, req *GetAuthCodeRequest, b2bToken, externalID string) (*GetAuthCodeResponse, error) {
-	timestamp := time.Now().Format(time.RFC3339)
+	b2bToken, err := c.resolveB2BToken(ctx, b2bToken)
+	if err != nil {
+		return nil, err
+	}
 
 	if req.MerchantID == "" {
 		req.MerchantID = c.Config.MerchantID
@@ -53,10 +54,12 @@ func (c Client) GetAuthCode(ctx context.Context, req *GetAuthCodeRequest, b2bTok
 
 	endpoint := GetAuthCodeEndpoint + "?" + urlValues.Encode()
 
-	signature := generateHmacSignature("GET", GetAuthCodeEndpoint, b2bToken, "", timestamp, c.Config.ClientSecret)
-	headers := c.BuildHeader(timestamp, signature, b2bToken, "", externalID)
-
-	resp, err := c.Execute(ctx, http.MethodGet, endpoint, headers, nil)
+	resp, err := c.send(ctx, &call{
+		operation: OperationGetAuthCode,
+		method:    http.MethodGet,
+		path:      endpoint,
+		sign:      c.hmacHeaders(http.MethodGet, GetAuthCodeEndpoint, nil, b2bToken, "", externalID),
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -71,14 +74,11 @@ func (c Client) GetAuthCode(ctx context.Context, req *GetAuthCodeRequest, b2bTok
 }
 
 func (c Client) GetCustomerAccessToken(ctx context.Context, authCode, accessTokenB2B string) (*GetAccessTokenResponse, error) {
-	timestamp := time.Now().Format(time.RFC3339)
-	signature, err := generateRSASignature(timestamp, c.Config.RsaPrivateKey, c.Config.ClientID)
+	accessTokenB2B, err := c.resolveB2BToken(ctx, accessTokenB2B)
 	if err != nil {
 		return nil, err
 	}
 
-	headers := c.BuildHeader(timestamp, signature, accessTokenB2B, "", "")
-
 	body, err := json.Marshal(
 		GetCustomerAccessTokenRequest{
 			GrantType: "authorization_code",
@@ -94,7 +94,14 @@ func (c Client) GetCustomerAccessToken(ctx context.Context, authCode, accessToke
 
 	endpoint := GetCustomerAccessTokenEndpoint
 
-	resp, err := c.Execute(ctx, http.MethodPost, endpoint, headers, body)
+	resp, err := c.send(ctx, &call{
+		operation: OperationGetCustomerAccessToken,
+		method:    http.MethodPost,
+		path:      endpoint,
+		body:      body,
+		sign:      c.accessTokenHeaders(accessTokenB2B),
+	})
+	c.observeTokenRefresh(ctx, TokenKindCustomer, err)
 	if err != nil {
 		return nil, err
 	}
@@ -187,12 +187,12 @@ B+PKt975LyM4bbmKw0AHz/jKoFTCPyDFgmA43i75VDjJ33ADALcLoPy333unC5I1
 			}))
 
 			cfg.EndpointBaseURL = server.URL
-			client, _ = directdebit.New(cfg)
 		})
 
-		It("returns an error", func() {
-			_, err := client.GetBusinessAccessToken(context.Background())
-			Expect(err).Should(HaveOccurred())
+		It("returns an error when creating the client", func() {
+			client, err := directdebit.New(cfg)
+			Expect(err).Should(MatchError(directdebit.ErrParsePEMBlock))
+			Expect(client).Should(BeNil())
 		})
 	})
 })
@@ -592,12 +592,12 @@ B+PKt975LyM4bbmKw0AHz/jKoFTCPyDFgmA43i75VDjJ33ADALcLoPy333unC5I1
 			}))
 
 			cfg.EndpointBaseURL = server.URL
-			client, _ = directdebit.New(cfg)
 		})
 
-		It("returns an error", func() {
-			_, err := client.GetCustomerAccessToken(context.Background(), authCode, accessTokenB2B)
-			Expect(err).Should(HaveOccurred())
+		It("returns an error when creating the client", func() {
+			client, err := directdebit.New(cfg)
+			Expect(err).Should(MatchError(directdebit.ErrParsePEMBlock))
+			Expect(client).Should(BeNil())
 		})
 	})
 })
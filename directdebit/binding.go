@@ -4,12 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
-	"time"
 )
 
 func (c Client) AccountBinding(ctx context.Context, req *AccountBindingRequest, b2bToken, externalID string) (*AccountBindingResponse, error) {
+	b2bToken, err := c.resolveB2BToken(ctx, b2bToken)
+	if err != nil {
+		return nil, err
+	}
 	endpoint := AccountBindingEndpoint
-	timestamp := time.Now().Format(time.RFC3339)
 
 	if req.MerchantID == "" {
 		req.MerchantID = c.Config.MerchantID
@@ -19,10 +21,13 @@ func (c Client) AccountBinding(ctx context.Context, req *AccountBindingRequest,
 		return nil, err
 	}
 
-	signature := generateHmacSignature("POST", endpoint, b2bToken, string(body), timestamp, c.Config.ClientSecret)
-	headers := c.BuildHeader(timestamp, signature, b2bToken, "", externalID)
-
-	resp, err := c.Execute(ctx, http.MethodPost, endpoint, headers, body)
+	resp, err := c.send(ctx, &call{
+		operation: OperationAccountBinding,
+		method:    http.MethodPost,
+		path:      endpoint,
+		body:      body,
+		sign:      c.hmacHeaders(http.MethodPost, endpoint, body, b2bToken, "", externalID),
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,207 @@
+package directdebit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultBindingTTL is how long a started binding can be completed.
+const DefaultBindingTTL = 15 * time.Minute
+
+var (
+	ErrBindingNotFound = errors.New("pending binding not found")
+	// ErrInvalidBindingState is returned by BindingFlow.Complete when the state of
+	// the callback does not belong to a pending binding, or its auth code does not
+	// match the one issued for it.
+	ErrInvalidBindingState = errors.New("invalid binding state")
+)
+
+// PendingBinding is a binding started by BindingFlow and waiting for the customer to
+// come back through the redirect URL.
+type PendingBinding struct {
+	State              string    `json:"state"`
+	PartnerReferenceNo string    `json:"partnerReferenceNo"`
+	AuthCode           string    `json:"authCode"`
+	MobileNumber       string    `json:"mobileNumber"`
+	CreatedAt          time.Time `json:"createdAt"`
+}
+
+// BindingStore keeps pending bindings between BindingFlow.Start and
+// BindingFlow.Complete. Take returns the binding stored for state and removes it in
+// one step, such as GETDEL on Redis, so that concurrent callbacks with the same
+// state cannot both bind the card. Take must return ErrBindingNotFound when no
+// unexpired binding is stored for state.
+type BindingStore interface {
+	Take(ctx context.Context, state string) (*PendingBinding, error)
+	Set(ctx context.Context, binding *PendingBinding, ttl time.Duration) error
+}
+
+// MemoryBindingStore is an in-memory BindingStore. Expired bindings are evicted when
+// they are taken and whenever a new binding is stored.
+type MemoryBindingStore struct {
+	bindings *ttlCache[string, PendingBinding]
+}
+
+func NewMemoryBindingStore() *MemoryBindingStore {
+	return &MemoryBindingStore{bindings: newTTLCache[string, PendingBinding]()}
+}
+
+func (s *MemoryBindingStore) Take(_ context.Context, state string) (*PendingBinding, error) {
+	binding, ok := s.bindings.take(state)
+	if !ok {
+		return nil, ErrBindingNotFound
+	}
+
+	return &binding, nil
+}
+
+func (s *MemoryBindingStore) Set(_ context.Context, binding *PendingBinding, ttl time.Duration) error {
+	s.bindings.set(binding.State, *binding, ttl)
+
+	return nil
+}
+
+// BoundCard is a card bound by BindingFlow.Complete. AuthCode acquires the B2B2C
+// access token of the customer, see Client.CustomerAccessToken.
+type BoundCard struct {
+	PublicUserID       string
+	AccountToken       string
+	TokenStatus        string
+	MaskedCard         string
+	BankCode           string
+	AuthCode           string
+	PartnerReferenceNo string
+}
+
+// BindingFlow binds cards in two steps. Start requests an auth code and keeps the
+// pending binding in Store, the customer is then sent through the Ayoconnect
+// binding page, and Complete binds the card when the customer comes back to the
+// redirect URL with the state issued by Start.
+type BindingFlow struct {
+	Client ClientInterface
+	// Store keeps pending bindings. Defaults to an in-memory store, which only
+	// works when callbacks are handled by the process that started the binding.
+	Store BindingStore
+	// TTL is how long a pending binding is kept. Defaults to DefaultBindingTTL.
+	TTL time.Duration
+	// NewID generates the state and partner reference number of a binding, and the
+	// external ID of each request. Defaults to 32 random digits.
+	NewID func() string
+}
+
+// NewBindingFlow returns a BindingFlow for client. A nil store uses a
+// MemoryBindingStore.
+func NewBindingFlow(client ClientInterface, store BindingStore) *BindingFlow {
+	if store == nil {
+		store = NewMemoryBindingStore()
+	}
+
+	return &BindingFlow{Client: client, Store: store}
+}
+
+// Start requests an auth code for req and stores the pending binding. The State of
+// req is replaced by a generated one, the other fields such as RedirectURL and
+// SeamlessData are sent as they are.
+func (f *BindingFlow) Start(ctx context.Context, req *GetAuthCodeRequest) (*PendingBinding, error) {
+	binding := &PendingBinding{
+		State:              f.newID(),
+		PartnerReferenceNo: f.newID(),
+		MobileNumber:       req.SeamlessData.MobileNumber,
+		CreatedAt:          time.Now(),
+	}
+
+	req.State = binding.State
+
+	resp, err := f.Client.GetAuthCode(ctx, req, "", f.newID())
+	if err != nil {
+		return nil, err
+	}
+	binding.AuthCode = resp.AuthCode
+
+	err = f.Store.Set(ctx, binding, f.ttl())
+	if err != nil {
+		return nil, err
+	}
+
+	return binding, nil
+}
+
+// Complete binds the card of the pending binding issued with state. authCode is the
+// auth code of the callback, when empty the one returned to Start is used. The
+// pending binding is taken from Store before the card is bound, so a concurrent
+// callback with the same state fails with ErrInvalidBindingState. When binding
+// fails the pending binding is stored again for the rest of its TTL, so that
+// Complete can be called again.
+func (f *BindingFlow) Complete(ctx context.Context, state, authCode string) (*BoundCard, error) {
+	if state == "" {
+		return nil, ErrInvalidBindingState
+	}
+
+	binding, err := f.Store.Take(ctx, state)
+	if errors.Is(err, ErrBindingNotFound) {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidBindingState, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	card, err := f.bind(ctx, binding, authCode)
+	if err != nil {
+		// a binding that cannot be stored again has to be started over
+		if ttl := f.ttl() - time.Since(binding.CreatedAt); ttl > 0 {
+			_ = f.Store.Set(ctx, binding, ttl)
+		}
+		return nil, err
+	}
+
+	return card, nil
+}
+
+func (f *BindingFlow) bind(ctx context.Context, binding *PendingBinding, authCode string) (*BoundCard, error) {
+	switch {
+	case authCode == "":
+		authCode = binding.AuthCode
+	case binding.AuthCode != "" && authCode != binding.AuthCode:
+		return nil, fmt.Errorf("%w: auth code does not match", ErrInvalidBindingState)
+	}
+
+	if authCode == "" {
+		return nil, fmt.Errorf("%w: missing auth code", ErrInvalidBindingState)
+	}
+
+	resp, err := f.Client.AccountBinding(ctx, &AccountBindingRequest{
+		PartnerReferenceNo: binding.PartnerReferenceNo,
+		AuthCode:           authCode,
+	}, "", f.newID())
+	if err != nil {
+		return nil, err
+	}
+
+	return &BoundCard{
+		PublicUserID:       resp.UserInfo.PublicUserID,
+		AccountToken:       resp.AccountToken,
+		TokenStatus:        resp.TokenStatus,
+		MaskedCard:         resp.AdditionalInfo.MaskedCard,
+		BankCode:           resp.AdditionalInfo.BankCode,
+		AuthCode:           authCode,
+		PartnerReferenceNo: binding.PartnerReferenceNo,
+	}, nil
+}
+
+func (f *BindingFlow) ttl() time.Duration {
+	if f.TTL <= 0 {
+		return DefaultBindingTTL
+	}
+
+	return f.TTL
+}
+
+func (f *BindingFlow) newID() string {
+	if f.NewID != nil {
+		return f.NewID()
+	}
+
+	return randomExternalID()
+}
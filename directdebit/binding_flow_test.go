@@ -0,0 +1,197 @@
+package directdebit_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit"
+	"github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit/directdebittest"
+)
+
+type failingBindingStore struct {
+	directdebit.BindingStore
+}
+
+func (failingBindingStore) Take(_ context.Context, _ string) (*directdebit.PendingBinding, error) {
+	return nil, errors.New("store unavailable")
+}
+
+// externalIDRecorder records the external IDs of the binding requests.
+type externalIDRecorder struct {
+	directdebit.ClientInterface
+	externalIDs []string
+}
+
+func (r *externalIDRecorder) GetAuthCode(ctx context.Context, req *directdebit.GetAuthCodeRequest, b2bToken string, externalID string) (*directdebit.GetAuthCodeResponse, error) {
+	r.externalIDs = append(r.externalIDs, externalID)
+	return r.ClientInterface.GetAuthCode(ctx, req, b2bToken, externalID)
+}
+
+func (r *externalIDRecorder) AccountBinding(ctx context.Context, req *directdebit.AccountBindingRequest, b2bToken string, externalID string) (*directdebit.AccountBindingResponse, error) {
+	r.externalIDs = append(r.externalIDs, externalID)
+	return r.ClientInterface.AccountBinding(ctx, req, b2bToken, externalID)
+}
+
+var _ = Describe("BindingFlow", func() {
+	var (
+		srv  *directdebittest.Server
+		flow *directdebit.BindingFlow
+		req  *directdebit.GetAuthCodeRequest
+	)
+
+	BeforeEach(func() {
+		srv = directdebittest.NewServer()
+
+		client, err := directdebit.New(srv.Config())
+		Expect(err).ShouldNot(HaveOccurred())
+
+		flow = directdebit.NewBindingFlow(client, nil)
+		req = &directdebit.GetAuthCodeRequest{
+			RedirectURL:  "https://merchant.example/binding/callback",
+			SeamlessData: directdebit.SeamlessData{MobileNumber: "081234567890", BankCode: "CENAIDJA"},
+		}
+	})
+
+	AfterEach(func() {
+		srv.Close()
+	})
+
+	When("the callback carries the state issued by Start", func() {
+		It("binds the card and forgets the pending binding", func() {
+			pending, err := flow.Start(context.Background(), req)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(pending.State).ShouldNot(BeEmpty())
+			Expect(req.State).Should(Equal(pending.State))
+			Expect(pending.PartnerReferenceNo).ShouldNot(Equal(pending.State))
+			Expect(pending.AuthCode).ShouldNot(BeEmpty())
+
+			card, err := flow.Complete(context.Background(), pending.State, pending.AuthCode)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(card.AccountToken).ShouldNot(BeEmpty())
+			Expect(card.PublicUserID).ShouldNot(BeEmpty())
+			Expect(card.BankCode).Should(Equal("CENAIDJA"))
+			Expect(card.AuthCode).Should(Equal(pending.AuthCode))
+			Expect(card.PartnerReferenceNo).Should(Equal(pending.PartnerReferenceNo))
+
+			bound, ok := srv.Card(card.AccountToken)
+			Expect(ok).Should(BeTrue())
+			Expect(bound.MobileNumber).Should(Equal("081234567890"))
+
+			_, err = flow.Complete(context.Background(), pending.State, pending.AuthCode)
+			Expect(err).Should(MatchError(directdebit.ErrInvalidBindingState))
+		})
+	})
+
+	When("the same callback is handled concurrently", func() {
+		It("binds the card once", func() {
+			pending, err := flow.Start(context.Background(), req)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			srv.Inject(directdebittest.Fault{Operation: directdebit.OperationAccountBinding, Delay: 50 * time.Millisecond})
+
+			var wg sync.WaitGroup
+			errs := make([]error, 2)
+			for i := range errs {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					_, errs[i] = flow.Complete(context.Background(), pending.State, pending.AuthCode)
+				}(i)
+			}
+			wg.Wait()
+
+			Expect(errs).Should(ContainElement(BeNil()))
+			Expect(errs).Should(ContainElement(MatchError(directdebit.ErrInvalidBindingState)))
+			Expect(srv.Cards()).Should(HaveLen(1))
+		})
+	})
+
+	When("requests are sent", func() {
+		It("uses a new external ID for each of them", func() {
+			recorder := &externalIDRecorder{ClientInterface: flow.Client}
+			flow.Client = recorder
+
+			pending, err := flow.Start(context.Background(), req)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			_, err = flow.Complete(context.Background(), pending.State, pending.AuthCode)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(recorder.externalIDs).Should(HaveLen(2))
+			Expect(recorder.externalIDs[0]).ShouldNot(Equal(recorder.externalIDs[1]))
+		})
+	})
+
+	When("the callback does not carry an auth code", func() {
+		It("uses the auth code returned to Start", func() {
+			pending, err := flow.Start(context.Background(), req)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			card, err := flow.Complete(context.Background(), pending.State, "")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(card.AuthCode).Should(Equal(pending.AuthCode))
+		})
+	})
+
+	When("the callback state is unknown", func() {
+		It("does not bind", func() {
+			_, err := flow.Start(context.Background(), req)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			_, err = flow.Complete(context.Background(), "forged", "authCode")
+			Expect(err).Should(MatchError(directdebit.ErrInvalidBindingState))
+			Expect(errors.Is(err, directdebit.ErrBindingNotFound)).Should(BeTrue())
+
+			_, err = flow.Complete(context.Background(), "", "authCode")
+			Expect(err).Should(MatchError(directdebit.ErrInvalidBindingState))
+			Expect(srv.Cards()).Should(BeEmpty())
+		})
+	})
+
+	When("the callback auth code does not match", func() {
+		It("does not bind", func() {
+			pending, err := flow.Start(context.Background(), req)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			_, err = flow.Complete(context.Background(), pending.State, "otherAuthCode")
+			Expect(err).Should(MatchError(directdebit.ErrInvalidBindingState))
+			Expect(srv.Cards()).Should(BeEmpty())
+
+			_, err = flow.Complete(context.Background(), pending.State, pending.AuthCode)
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	When("binding fails", func() {
+		It("keeps the pending binding so it can be completed again", func() {
+			pending, err := flow.Start(context.Background(), req)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			srv.Inject(directdebittest.Fault{
+				Operation:    directdebit.OperationAccountBinding,
+				ResponseCode: "5000701",
+				Times:        1,
+			})
+
+			_, err = flow.Complete(context.Background(), pending.State, pending.AuthCode)
+			Expect(err).Should(MatchError(directdebit.ErrServerError))
+
+			_, err = flow.Complete(context.Background(), pending.State, pending.AuthCode)
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	When("the store fails", func() {
+		It("returns the store error", func() {
+			flow.Store = failingBindingStore{}
+
+			_, err := flow.Complete(context.Background(), "state", "authCode")
+			Expect(err).Should(MatchError("store unavailable"))
+		})
+	})
+})
@@ -0,0 +1,52 @@
+package directdebit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// CancelDebit voids a pending debit before it settles. A *ResponseError whose code
+// satisfies IsDebitNotCancellableError means the debit has already settled and must
+// be refunded instead.
+func (c Client) CancelDebit(
+	ctx context.Context,
+	req *CancelDebitRequest,
+	b2bToken,
+	b2b2cToken,
+	externalID string,
+) (*CancelDebitResponse, error) {
+	b2bToken, err := c.resolveB2BToken(ctx, b2bToken)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := CancelDebitEndpoint
+
+	if req.MerchantID == "" {
+		req.MerchantID = c.Config.MerchantID
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.send(ctx, &call{
+		operation: OperationCancelDebit,
+		method:    http.MethodPost,
+		path:      endpoint,
+		body:      body,
+		sign:      c.hmacHeaders(http.MethodPost, endpoint, body, b2bToken, b2b2cToken, externalID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	respEntity := CancelDebitResponse{}
+	err = json.Unmarshal(resp, &respEntity)
+	if err != nil {
+		return nil, err
+	}
+
+	return &respEntity, nil
+}
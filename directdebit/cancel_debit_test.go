@@ -0,0 +1,127 @@
+package directdebit_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit"
+)
+
+var _ = Describe("CancelDebit", func() {
+	var (
+		client     *directdebit.Client
+		cfg        *directdebit.Config
+		server     *httptest.Server
+		request    *directdebit.CancelDebitRequest
+		b2bToken   string
+		b2b2cToken string
+		externalID string
+	)
+
+	BeforeEach(func() {
+		cfg = &directdebit.Config{
+			ClientID:   "123",
+			MerchantID: "123",
+			HTTPClient: &http.Client{},
+			Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		}
+
+		request = &directdebit.CancelDebitRequest{
+			OriginalPartnerReferenceNo: "t4tn57kibeunbam9dtr89urv8h2jbem9",
+			Reason:                     "checkout timeout",
+			Amount: directdebit.Amount{
+				Value:    "1000000",
+				Currency: "IDR",
+			},
+		}
+		b2bToken = "sampleB2bToken"
+		b2b2cToken = "sampleB2b2cToken"
+		externalID = "sampleExternalID"
+	})
+
+	AfterEach(func() {
+		if server != nil {
+			server.Close()
+		}
+	})
+
+	When("cancel debit error", func() {
+		It("return error when failed to execute http", func() {
+			client, _ = directdebit.New(cfg)
+			resp, err := client.CancelDebit(context.Background(), request, b2bToken, b2b2cToken, externalID)
+			Expect(err).To(HaveOccurred())
+			Expect(resp).To(BeNil())
+		})
+
+		It("return error when failed to unmarshal JSON", func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`invalid json`))
+			}))
+
+			cfg.EndpointBaseURL = server.URL
+			client, _ = directdebit.New(cfg)
+
+			resp, err := client.CancelDebit(context.Background(), request, b2bToken, b2b2cToken, externalID)
+			Expect(err).To(HaveOccurred())
+			Expect(resp).To(BeNil())
+		})
+
+		It("return a response error when the debit has already settled", func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"responseCode":"4045700","responseMessage":"Invalid Transaction Status"}`))
+			}))
+
+			cfg.EndpointBaseURL = server.URL
+			client, _ = directdebit.New(cfg)
+
+			resp, err := client.CancelDebit(context.Background(), request, b2bToken, b2b2cToken, externalID)
+			Expect(resp).To(BeNil())
+
+			var respErr *directdebit.ResponseError
+			Expect(errors.As(err, &respErr)).Should(BeTrue())
+			Expect(directdebit.IsDebitNotCancellableError(respErr.ResponseCode)).Should(BeTrue())
+			Expect(directdebit.IsDebitNotFoundError(respErr.ResponseCode)).Should(BeFalse())
+		})
+	})
+
+	When("cancel debit successful", func() {
+		It("return correct response", func() {
+			var gotRequest directdebit.CancelDebitRequest
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				_ = json.Unmarshal(body, &gotRequest)
+
+				respJSON, _ := json.Marshal(directdebit.CancelDebitResponse{
+					ResponseCode:               "2005700",
+					ResponseMessage:            "success",
+					OriginalPartnerReferenceNo: gotRequest.OriginalPartnerReferenceNo,
+					CancelTime:                 "2024-01-01T10:00:00+07:00",
+				})
+				w.WriteHeader(http.StatusOK)
+				w.Write(respJSON)
+			}))
+
+			cfg.EndpointBaseURL = server.URL
+			cfg.MerchantID = "MEKARI"
+			client, _ = directdebit.New(cfg)
+
+			resp, err := client.CancelDebit(context.Background(), request, b2bToken, b2b2cToken, externalID)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(gotRequest.MerchantID).Should(Equal("MEKARI"))
+			Expect(gotRequest.Reason).Should(Equal("checkout timeout"))
+			Expect(resp.OriginalPartnerReferenceNo).Should(Equal("t4tn57kibeunbam9dtr89urv8h2jbem9"))
+			Expect(resp.CancelTime).ShouldNot(BeEmpty())
+		})
+	})
+})
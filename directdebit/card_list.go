@@ -0,0 +1,44 @@
+package directdebit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// GetCardList returns the cards bound to req.PublicUserID.
+func (c Client) GetCardList(ctx context.Context, req *GetCardsRequest, b2bToken, externalID string) (*GetCardListResponse, error) {
+	b2bToken, err := c.resolveB2BToken(ctx, b2bToken)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := GetCardListEndpoint
+
+	if req.MerchantID == "" {
+		req.MerchantID = c.Config.MerchantID
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.send(ctx, &call{
+		operation: OperationGetCardList,
+		method:    http.MethodPost,
+		path:      endpoint,
+		body:      body,
+		sign:      c.hmacHeaders(http.MethodPost, endpoint, body, b2bToken, "", externalID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	respEntity := GetCardListResponse{}
+	err = json.Unmarshal(resp, &respEntity)
+	if err != nil {
+		return nil, err
+	}
+
+	return &respEntity, nil
+}
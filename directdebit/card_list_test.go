@@ -0,0 +1,105 @@
+package directdebit_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit"
+)
+
+var _ = Describe("GetCardList", func() {
+	var (
+		client     *directdebit.Client
+		cfg        *directdebit.Config
+		server     *httptest.Server
+		request    *directdebit.GetCardsRequest
+		b2bToken   string
+		externalID string
+	)
+
+	BeforeEach(func() {
+		cfg = &directdebit.Config{
+			ClientID:   "123",
+			MerchantID: "123",
+			HTTPClient: &http.Client{},
+		}
+
+		request = &directdebit.GetCardsRequest{
+			PartnerReferenceNo: "partnerReferenceNo",
+			PublicUserID:       "TEST",
+		}
+		b2bToken = "sampleB2bToken"
+		externalID = "sampleExternalID"
+	})
+
+	AfterEach(func() {
+		if server != nil {
+			server.Close()
+		}
+	})
+
+	When("get card list error", func() {
+		It("return error when failed to execute http", func() {
+			client, _ = directdebit.New(cfg)
+			resp, err := client.GetCardList(context.Background(), request, b2bToken, externalID)
+			Expect(err).To(HaveOccurred())
+			Expect(resp).To(BeNil())
+		})
+
+		It("return error when failed to unmarshal JSON", func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`invalid json`))
+			}))
+
+			cfg.EndpointBaseURL = server.URL
+			client, _ = directdebit.New(cfg)
+
+			resp, err := client.GetCardList(context.Background(), request, b2bToken, externalID)
+			Expect(err).To(HaveOccurred())
+			Expect(resp).To(BeNil())
+		})
+	})
+
+	When("get card list successful", func() {
+		It("return the bound cards", func() {
+			var gotRequest directdebit.GetCardsRequest
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				_ = json.Unmarshal(body, &gotRequest)
+
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{
+					"responseCode": "2000100",
+					"responseMessage": "success",
+					"partnerReferenceNo": "partnerReferenceNo",
+					"userInfo": {"publicUserId": "TEST"},
+					"cards": [
+						{"maskedCard": "1234", "bankCode": "BRI", "accountToken": "token1", "tokenStatus": "ACTIVE"},
+						{"maskedCard": "5678", "bankCode": "MANDIRI", "accountToken": "token2", "tokenStatus": "INACTIVE"}
+					]
+				}`))
+			}))
+
+			cfg.EndpointBaseURL = server.URL
+			cfg.MerchantID = "MEKARI"
+			client, _ = directdebit.New(cfg)
+
+			resp, err := client.GetCardList(context.Background(), request, b2bToken, externalID)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(gotRequest.MerchantID).Should(Equal("MEKARI"))
+			Expect(gotRequest.PublicUserID).Should(Equal("TEST"))
+			Expect(resp.Cards).Should(Equal([]directdebit.Card{
+				{MaskedCard: "1234", BankCode: "BRI", AccountToken: "token1", TokenStatus: "ACTIVE"},
+				{MaskedCard: "5678", BankCode: "MANDIRI", AccountToken: "token2", TokenStatus: "INACTIVE"},
+			}))
+		})
+	})
+})
@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"strings"
+	"time"
 
 	"log/slog"
 )
@@ -19,11 +21,42 @@ const (
 	DebitEndpoint                  = "/api/v1.0/debit/payment-host-to-host"
 	UnbindEndpoint                 = "/api/v1.0/registration-account-unbinding"
 	DebitStatusEndpoint            = "/api/v1.0/debit/status"
+	VerifyOTPEndpoint              = "/api/v1.0/otp-verification"
+	GetCardListEndpoint            = "/api/v1.0/card-list"
+	RefundEndpoint                 = "/api/v1.0/debit/refund"
+	RefundStatusEndpoint           = "/api/v1.0/debit/refund/status"
+	CancelDebitEndpoint            = "/api/v1.0/debit/cancel"
 )
 
+// MaxErrorBodySize is the number of bytes of a failed response body kept in
+// ResponseError.Body.
+const MaxErrorBodySize = 1024
+
+// RequestIDHeaders are the response headers that may carry the ID a gateway or
+// Ayoconnect assigned to a request, in order of preference.
+var RequestIDHeaders = []string{
+	"X-Request-Id",
+	"X-Correlation-Id",
+	"X-Amzn-Trace-Id",
+	"X-EXTERNAL-ID",
+}
+
 func New(c *Config) (*Client, error) {
 	// @TODO: clone and override http client timeout here
-	return &Client{Config: c}, nil
+	signer, err := newConfigSigner(c)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &Client{Config: c, signer: signer}
+	client.tokens = newTokenManager(client.GetBusinessAccessToken, c.TokenRefreshLeeway)
+
+	client.customerTokenStore = c.CustomerTokenStore
+	if client.customerTokenStore == nil {
+		client.customerTokenStore = NewMemoryCustomerTokenStore()
+	}
+
+	return client, nil
 }
 
 func (c Client) SetHeaders(req *http.Request, headers RequestHeader) *http.Request {
@@ -57,42 +90,110 @@ func (c Client) SetHeaders(req *http.Request, headers RequestHeader) *http.Reque
 //
 //	also use http.NewRequestWithContext instead of NewRequest
 func (c Client) Execute(ctx context.Context, method string, path string, headers RequestHeader, jsonBytes []byte) ([]byte, error) {
-	req, err := http.NewRequest(method, c.Config.EndpointBaseURL+path, bytes.NewReader(jsonBytes))
+	resp, err := c.handler()(ctx, &Request{Method: method, Path: path, Header: headers, Body: jsonBytes, Attempt: 1})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+// roundTrip sends req to Ayoconnect. It is the last Handler of the middleware chain.
+func (c Client) roundTrip(ctx context.Context, r *Request) (resp *Response, err error) {
+	defer func(start time.Time) {
+		c.observeRequest(ctx, r, start, resp, err)
+	}(time.Now())
+
+	var dump *DebugDump
+	if c.Config.Debug || c.Config.DebugHook != nil {
+		dump = newDebugDump(r)
+		defer func() {
+			dump.Err = err
+			c.debug(ctx, dump)
+		}()
+	}
+
+	req, err := http.NewRequest(r.Method, c.Config.EndpointBaseURL+r.Path, bytes.NewReader(r.Body))
 	if err != nil {
 		return nil, err
 	}
 	req = req.WithContext(ctx)
-	req = c.SetHeaders(req, headers)
+	req = c.SetHeaders(req, r.Header)
+
+	for name, values := range r.ExtraHeader {
+		req.Header[name] = values
+	}
+
+	if dump != nil {
+		dump.HTTPHeader = req.Header.Clone()
+	}
 
 	res, err := c.Config.HTTPClient.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, wrapTimeout(err)
 	}
 
 	defer res.Body.Close()
 
 	resBody, err := io.ReadAll(res.Body)
 	if err != nil {
-		return nil, err
+		return nil, wrapTimeout(err)
+	}
+
+	if dump != nil {
+		dump.StatusCode = res.StatusCode
+		dump.ResponseHeader = res.Header
+		dump.ResponseBody = resBody
 	}
 
 	if !(res.StatusCode == http.StatusOK || res.StatusCode == http.StatusAccepted) {
+		redactor := c.redactor()
 		c.Config.Logger.ErrorContext(ctx, "failed to execute request",
-			slog.String("method", method),
-			slog.String("path", path),
-			slog.String("request_body", string(jsonBytes)),
+			slog.String("method", r.Method),
+			slog.String("path", redactor.RedactPath(r.Path)),
+			slog.Any("request_headers", redactor.RedactHeader(req.Header)),
+			slog.String("request_body", redactor.RedactJSON(r.Body)),
 			slog.String("response_status", res.Status),
-			slog.String("response_body", string(resBody)),
+			slog.String("response_body", redactor.RedactJSON(resBody)),
 		)
-		errResp := ResponseError{}
-		err := json.Unmarshal(resBody, &errResp)
-		if err != nil {
-			return nil, err
+
+		respErr := newResponseError(r.Method, r.Path, res, resBody, redactor)
+		c.rejectTokens(r.Header, respErr)
+
+		return nil, respErr
+	}
+
+	return &Response{StatusCode: res.StatusCode, Header: res.Header, Body: resBody}, nil
+}
+
+// newResponseError builds the error of a failed response. The SNAP fields are only
+// filled when body is SNAP JSON, the rest is always filled. Errors end up in logs
+// and traces, so the query string is dropped from the endpoint and the body and
+// headers are redacted.
+func newResponseError(method, path string, res *http.Response, body []byte, redactor *Redactor) *ResponseError {
+	errResp := ResponseError{}
+	// a body that is not JSON leaves the SNAP fields empty
+	_ = json.Unmarshal(body, &errResp)
+
+	errResp.StatusCode = res.StatusCode
+	errResp.Method = method
+	errResp.Endpoint, _, _ = strings.Cut(path, "?")
+	errResp.Header = redactor.RedactHeader(res.Header)
+
+	for _, header := range RequestIDHeaders {
+		if id := res.Header.Get(header); id != "" {
+			errResp.RequestID = id
+			break
 		}
-		return nil, &errResp
 	}
 
-	return resBody, nil
+	redacted := redactor.RedactJSON(body)
+	if len(redacted) > MaxErrorBodySize {
+		redacted = redacted[:MaxErrorBodySize]
+	}
+	errResp.Body = redacted
+
+	return &errResp
 }
 
 func (c Client) BuildHeader(timestamp string, signature string, b2bToken string, b2b2cToken string, externalID string) RequestHeader {
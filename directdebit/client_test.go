@@ -3,8 +3,12 @@ package directdebit_test
 import (
 	"bytes"
 	"context"
+	"errors"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit"
@@ -152,3 +156,103 @@ func TestExecuteErrorScenarios(t *testing.T) {
 		t.Errorf("Expected an error while reading the response body, but got none")
 	}
 }
+
+func TestExecuteErrorIsRedacted(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Authorization-Customer", "Bearer secret-token")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"responseCode":"4005402","responseMessage":"Invalid Mandatory Field","accessToken":"secret-token"}`))
+	}))
+	defer ts.Close()
+
+	client := directdebit.Client{
+		Config: &directdebit.Config{
+			EndpointBaseURL: ts.URL,
+			HTTPClient:      &http.Client{},
+			Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		},
+	}
+
+	_, err := client.Execute(context.Background(), http.MethodGet, directdebit.DebitStatusEndpoint+"?mobileNumber=081234567890", directdebit.RequestHeader{}, nil)
+
+	var respErr *directdebit.ResponseError
+	if !errors.As(err, &respErr) {
+		t.Fatalf("Expected a *ResponseError, but got: %v", err)
+	}
+
+	if respErr.Endpoint != directdebit.DebitStatusEndpoint {
+		t.Errorf("Expected endpoint %s without its query, but got %s", directdebit.DebitStatusEndpoint, respErr.Endpoint)
+	}
+
+	if strings.Contains(respErr.Body, "secret-token") {
+		t.Errorf("Expected the body to be redacted, but got %s", respErr.Body)
+	}
+
+	if got := respErr.Header.Get("Authorization-Customer"); got != directdebit.DefaultRedactionMask {
+		t.Errorf("Expected the header to be redacted, but got %q", got)
+	}
+}
+
+func TestExecuteNonJSONErrorBody(t *testing.T) {
+	tests := map[string]struct {
+		status      int
+		body        string
+		code        directdebit.ResponseCode
+		wantBodyLen int
+	}{
+		"html":  {http.StatusBadGateway, "<html><body>502 Bad Gateway</body></html>", "", 41},
+		"empty": {http.StatusServiceUnavailable, "", "", 0},
+		"snap":  {http.StatusForbidden, `{"responseCode": "4035414", "responseMessage": "Insufficient Funds"}`, "4035414", 65},
+		"large": {http.StatusInternalServerError, strings.Repeat("x", 4096), "", directdebit.MaxErrorBodySize},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Request-Id", "request-"+name)
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.body))
+			}))
+			defer ts.Close()
+
+			client := directdebit.Client{
+				Config: &directdebit.Config{
+					EndpointBaseURL: ts.URL,
+					HTTPClient:      &http.Client{},
+					Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+				},
+			}
+
+			_, err := client.Execute(context.Background(), http.MethodPost, directdebit.DebitEndpoint, directdebit.RequestHeader{}, nil)
+
+			var respErr *directdebit.ResponseError
+			if !errors.As(err, &respErr) {
+				t.Fatalf("Expected a *ResponseError, but got: %v", err)
+			}
+
+			if respErr.StatusCode != tc.status {
+				t.Errorf("Expected status %d, but got %d", tc.status, respErr.StatusCode)
+			}
+
+			if respErr.ResponseCode != tc.code {
+				t.Errorf("Expected response code %q, but got %q", tc.code, respErr.ResponseCode)
+			}
+
+			if respErr.Endpoint != directdebit.DebitEndpoint || respErr.Method != http.MethodPost {
+				t.Errorf("Expected endpoint %s %s, but got %s %s", http.MethodPost, directdebit.DebitEndpoint, respErr.Method, respErr.Endpoint)
+			}
+
+			if respErr.RequestID != "request-"+name {
+				t.Errorf("Expected request ID %q, but got %q", "request-"+name, respErr.RequestID)
+			}
+
+			if len(respErr.Body) != tc.wantBodyLen {
+				t.Errorf("Expected body of %d bytes, but got %d", tc.wantBodyLen, len(respErr.Body))
+			}
+
+			if err.Error() == "" {
+				t.Errorf("Expected a non empty error message")
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package directdebit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+var ErrCustomerTokenNotFound = errors.New("customer access token not found")
+
+// CustomerTokenKey identifies the B2B2C access token of a customer's bound card.
+type CustomerTokenKey struct {
+	PublicUserID string
+	AccountToken string
+}
+
+// CustomerTokenStore keeps B2B2C access tokens returned by GetCustomerAccessToken.
+// Get must return ErrCustomerTokenNotFound when no unexpired token is stored for key.
+type CustomerTokenStore interface {
+	Get(ctx context.Context, key CustomerTokenKey) (string, error)
+	Set(ctx context.Context, key CustomerTokenKey, token string, ttl time.Duration) error
+	Delete(ctx context.Context, key CustomerTokenKey) error
+}
+
+// MemoryCustomerTokenStore is an in-memory CustomerTokenStore. Expired tokens are
+// evicted when they are looked up and whenever a new token is stored.
+type MemoryCustomerTokenStore struct {
+	tokens *ttlCache[CustomerTokenKey, string]
+}
+
+func NewMemoryCustomerTokenStore() *MemoryCustomerTokenStore {
+	return &MemoryCustomerTokenStore{tokens: newTTLCache[CustomerTokenKey, string]()}
+}
+
+func (s *MemoryCustomerTokenStore) Get(_ context.Context, key CustomerTokenKey) (string, error) {
+	token, ok := s.tokens.get(key)
+	if !ok {
+		return "", ErrCustomerTokenNotFound
+	}
+
+	return token, nil
+}
+
+func (s *MemoryCustomerTokenStore) Set(_ context.Context, key CustomerTokenKey, token string, ttl time.Duration) error {
+	s.tokens.set(key, token, ttl)
+
+	return nil
+}
+
+func (s *MemoryCustomerTokenStore) Delete(_ context.Context, key CustomerTokenKey) error {
+	s.tokens.delete(key)
+
+	return nil
+}
+
+// StoreCustomerAccessToken saves a token returned by GetCustomerAccessToken in the
+// configured CustomerTokenStore. The token is kept until ExpiredIn, shortened by
+// the token refresh leeway so that it is never used right before it expires. The
+// leeway is at most half of the token lifetime, and a token without ExpiredIn is
+// kept for DefaultTokenLifetime.
+func (c Client) StoreCustomerAccessToken(ctx context.Context, key CustomerTokenKey, resp *GetAccessTokenResponse) error {
+	if resp.AccessToken == "" {
+		return ErrEmptyAccessToken
+	}
+
+	lifetime := time.Duration(resp.ExpiredIn) * time.Second
+	if lifetime <= 0 {
+		lifetime = DefaultTokenLifetime
+	}
+
+	leeway := c.Config.TokenRefreshLeeway
+	if leeway <= 0 {
+		leeway = DefaultTokenRefreshLeeway
+	}
+	if leeway > lifetime/2 {
+		leeway = lifetime / 2
+	}
+
+	return c.customerTokens().Set(ctx, key, resp.AccessToken, lifetime-leeway)
+}
+
+// InvalidateCustomerAccessToken deletes the B2B2C access token stored for key, so
+// that the next call to CustomerAccessToken requests a new one.
+func (c Client) InvalidateCustomerAccessToken(ctx context.Context, key CustomerTokenKey) error {
+	return c.customerTokens().Delete(ctx, key)
+}
+
+// rejectedCustomerTokenCaseCodes are the case codes of the HTTP 401 responses
+// rejecting a B2B2C access token as invalid or unknown.
+var rejectedCustomerTokenCaseCodes = []string{"02", "04"}
+
+// isCustomerTokenRejected reports whether err rejects the B2B2C access token the
+// request was sent with.
+func isCustomerTokenRejected(err error) bool {
+	var respErr *ResponseError
+	if !errors.As(err, &respErr) {
+		return false
+	}
+
+	code := respErr.ResponseCode
+
+	return code.HTTPCode() == http.StatusUnauthorized && slices.Contains(rejectedCustomerTokenCaseCodes, code.CaseCode())
+}
+
+// rejectCustomerToken deletes the token stored for key when err rejects it. A store
+// that fails to delete it leaves err unchanged.
+func (c Client) rejectCustomerToken(ctx context.Context, key CustomerTokenKey, err error) {
+	if isCustomerTokenRejected(err) {
+		_ = c.InvalidateCustomerAccessToken(ctx, key)
+	}
+}
+
+// CustomerAccessToken returns the stored B2B2C access token for key. When none is
+// stored and authCode is not empty, a new token is requested with authCode and
+// stored before it is returned. Otherwise ErrCustomerTokenNotFound is returned.
+func (c Client) CustomerAccessToken(ctx context.Context, key CustomerTokenKey, authCode string) (string, error) {
+	token, err := c.customerTokens().Get(ctx, key)
+	if err == nil {
+		return token, nil
+	}
+
+	if !errors.Is(err, ErrCustomerTokenNotFound) || authCode == "" {
+		return "", err
+	}
+
+	resp, err := c.GetCustomerAccessToken(ctx, authCode, "")
+	if err != nil {
+		return "", err
+	}
+
+	err = c.StoreCustomerAccessToken(ctx, key, resp)
+	if err != nil {
+		return "", err
+	}
+
+	return resp.AccessToken, nil
+}
+
+// DebitCustomer calls Debit with the B2B2C access token stored for the customer and
+// card in req, acquiring a new one with authCode when none is stored. A token that
+// Ayoconnect rejects is deleted from the store.
+func (c Client) DebitCustomer(ctx context.Context, req *DebitRequest, authCode, externalID string) (*DebitResponse, error) {
+	key := CustomerTokenKey{
+		PublicUserID: req.AdditionalInfo.PublicUserID,
+		AccountToken: req.BankCardToken,
+	}
+
+	b2b2cToken, err := c.CustomerAccessToken(ctx, key, authCode)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.Debit(ctx, req, "", b2b2cToken, externalID)
+	c.rejectCustomerToken(ctx, key, err)
+
+	return resp, err
+}
+
+// UnbindCustomer calls Unbind with the B2B2C access token stored for the customer
+// and card in req, acquiring a new one with authCode when none is stored. A token
+// that Ayoconnect rejects is deleted from the store.
+func (c Client) UnbindCustomer(ctx context.Context, req *AccountUnbindRequest, authCode, externalID string) (*AccountUnbindResponse, error) {
+	key := CustomerTokenKey{
+		PublicUserID: req.AdditionalInfo.PublicUserID,
+		AccountToken: req.AdditionalInfo.AccountToken,
+	}
+
+	b2b2cToken, err := c.CustomerAccessToken(ctx, key, authCode)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.Unbind(ctx, req, "", b2b2cToken, externalID)
+	c.rejectCustomerToken(ctx, key, err)
+
+	return resp, err
+}
+
+func (c Client) customerTokens() CustomerTokenStore {
+	if c.customerTokenStore != nil {
+		return c.customerTokenStore
+	}
+
+	if c.Config.CustomerTokenStore != nil {
+		return c.Config.CustomerTokenStore
+	}
+
+	// clients that were not created with New have nowhere to keep tokens between calls
+	return NewMemoryCustomerTokenStore()
+}
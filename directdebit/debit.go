@@ -4,12 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
-	"time"
 )
 
 func (c Client) Debit(ctx context.Context, req *DebitRequest, b2bToken, b2b2cToken, externalID string) (*DebitResponse, error) {
+	b2bToken, err := c.resolveB2BToken(ctx, b2bToken)
+	if err != nil {
+		return nil, err
+	}
 	endpoint := DebitEndpoint
-	timestamp := time.Now().Format(time.RFC3339)
 
 	if req.MerchantID == "" {
 		req.MerchantID = c.Config.MerchantID
@@ -19,10 +21,13 @@ func (c Client) Debit(ctx context.Context, req *DebitRequest, b2bToken, b2b2cTok
 		return nil, err
 	}
 
-	signature := generateHmacSignature("POST", endpoint, b2bToken, string(body), timestamp, c.Config.ClientSecret)
-	headers := c.BuildHeader(timestamp, signature, b2bToken, b2b2cToken, externalID)
-
-	resp, err := c.Execute(ctx, http.MethodPost, endpoint, headers, body)
+	resp, err := c.send(ctx, &call{
+		operation: OperationDebit,
+		method:    http.MethodPost,
+		path:      endpoint,
+		body:      body,
+		sign:      c.hmacHeaders(http.MethodPost, endpoint, body, b2bToken, b2b2cToken, externalID),
+	})
 	if err != nil {
 		return nil, err
 	}
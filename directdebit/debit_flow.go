@@ -0,0 +1,308 @@
+package directdebit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/exp/slices"
+)
+
+// ErrUnexpectedDebitStep is returned when a DebitResult is resumed with an action
+// its step does not wait for, such as verifying the OTP of a completed debit.
+var ErrUnexpectedDebitStep = errors.New("unexpected debit step")
+
+// DebitStep is what a debit started by DebitFlow waits for.
+type DebitStep string
+
+const (
+	// DebitStepCompleted means the customer has been charged.
+	DebitStepCompleted DebitStep = "completed"
+	// DebitStepRequiresOTP means the customer received an OTP that must be passed
+	// to DebitFlow.VerifyOTP.
+	DebitStepRequiresOTP DebitStep = "requires_otp"
+	// DebitStepRequiresRedirect means the customer must approve the debit at
+	// DebitResult.RedirectURL, they are sent back to the URLParam of the debit.
+	DebitStepRequiresRedirect DebitStep = "requires_redirect"
+	// DebitStepPending means the outcome is not known yet, call DebitFlow.Resume
+	// later or wait for the debit notification.
+	DebitStepPending DebitStep = "pending"
+	// DebitStepFailed means the debit ended without charging the customer, or was
+	// refunded or canceled.
+	DebitStepFailed DebitStep = "failed"
+)
+
+// DebitCard is the bound card charged by DebitFlow.
+type DebitCard struct {
+	PublicUserID string `json:"publicUserId"`
+	AccountToken string `json:"accountToken"`
+	BankCode     string `json:"bankCode,omitempty"`
+	// AuthCode acquires a B2B2C access token when none is stored for the card, see
+	// Client.CustomerAccessToken.
+	AuthCode string `json:"authCode,omitempty"`
+}
+
+// DebitFlowRequest describes a debit started by DebitFlow.
+type DebitFlowRequest struct {
+	Card    DebitCard
+	Amount  Amount
+	Remarks string
+	// OTPAllowed lets Ayoconnect ask the customer for an OTP.
+	OTPAllowed bool
+	// URLParam holds the URLs the customer is sent back to after a redirect, and
+	// the URL notifications are posted to.
+	URLParam []URLParam
+}
+
+// DebitResult is the state of a debit started by DebitFlow. It can be persisted and
+// passed back to DebitFlow.Resume or DebitFlow.VerifyOTP to continue the debit.
+type DebitResult struct {
+	Step DebitStep `json:"step"`
+	Card DebitCard `json:"card"`
+	// PartnerReferenceNo and ExternalID are those of the Debit request,
+	// ExternalID is the one DebitStatus is queried with.
+	PartnerReferenceNo string `json:"partnerReferenceNo"`
+	ExternalID         string `json:"externalId"`
+	// ReferenceNo is the reference Ayoconnect assigned to the debit, it can be empty
+	// until the debit is resumed.
+	ReferenceNo string `json:"referenceNo,omitempty"`
+	// RedirectURL is set when Step is DebitStepRequiresRedirect.
+	RedirectURL string `json:"redirectUrl,omitempty"`
+	// TransactionStatus is the last known LatestTransactionStatus, if any.
+	TransactionStatus string `json:"transactionStatus,omitempty"`
+	Amount            Amount `json:"amount"`
+}
+
+// DebitFlow charges bound cards, acquiring the access tokens the debit needs and
+// telling the caller what the debit waits for next.
+type DebitFlow struct {
+	Client ClientInterface
+	// NewID generates partner reference numbers and external IDs. Defaults to 32
+	// random digits.
+	NewID func() string
+}
+
+// NewDebitFlow returns a DebitFlow for client.
+func NewDebitFlow(client ClientInterface) *DebitFlow {
+	return &DebitFlow{Client: client}
+}
+
+// Start debits req.Card with SafeDebit, so that an ambiguous failure is reconciled
+// before a result is returned. A debit whose outcome is still unknown after
+// reconciliation is returned as DebitStepPending rather than as an error. Declined
+// debits return the *ResponseError of Ayoconnect.
+func (f *DebitFlow) Start(ctx context.Context, req *DebitFlowRequest) (*DebitResult, error) {
+	b2b2cToken, err := f.customerToken(ctx, req.Card)
+	if err != nil {
+		return nil, err
+	}
+
+	otpAllowed := ""
+	if req.OTPAllowed {
+		otpAllowed = "YES"
+	}
+
+	debitReq := &DebitRequest{
+		PartnerReferenceNo: f.newID(),
+		BankCardToken:      req.Card.AccountToken,
+		URLParam:           req.URLParam,
+		Amount:             req.Amount,
+		AdditionalInfo: DebitAdditionalInfo{
+			PublicUserID: req.Card.PublicUserID,
+			Remarks:      req.Remarks,
+			BankCode:     req.Card.BankCode,
+			OtpAllowed:   otpAllowed,
+		},
+	}
+
+	result := &DebitResult{
+		Step:               DebitStepPending,
+		Card:               req.Card,
+		PartnerReferenceNo: debitReq.PartnerReferenceNo,
+		ExternalID:         f.newID(),
+		Amount:             req.Amount,
+	}
+
+	resp, err := f.Client.SafeDebit(ctx, debitReq, "", b2b2cToken, result.ExternalID)
+	switch {
+	case errors.Is(err, ErrDebitUnresolved):
+		return result, nil
+	case isOTPSentError(err):
+		result.Step = DebitStepRequiresOTP
+		// VerifyOTP looks the reference up again when it is still unknown
+		result.ReferenceNo, _ = f.referenceNo(ctx, result, err)
+		return result, nil
+	case err != nil:
+		f.rejectCustomerToken(ctx, req.Card, err)
+		return nil, err
+	}
+
+	result.update(resp, req.OTPAllowed)
+
+	return result, nil
+}
+
+// Resume queries DebitStatus for a debit that is not completed or failed, and
+// returns its updated result. A debit still in progress keeps waiting for the OTP
+// or redirect it was waiting for.
+func (f *DebitFlow) Resume(ctx context.Context, result *DebitResult) (*DebitResult, error) {
+	if result.Step == DebitStepCompleted || result.Step == DebitStepFailed {
+		return result, nil
+	}
+
+	status, err := f.Client.DebitStatus(ctx, "", result.ExternalID, f.newID())
+	if err != nil {
+		return nil, err
+	}
+
+	resumed := *result
+	resumed.TransactionStatus = status.LatestTransactionStatus
+	if status.ReferenceNo != "" {
+		resumed.ReferenceNo = status.ReferenceNo
+	}
+
+	switch {
+	case status.LatestTransactionStatus == TransactionStatusSuccess:
+		resumed.Step = DebitStepCompleted
+	case slices.Contains(FinalTransactionStatus, status.LatestTransactionStatus):
+		resumed.Step = DebitStepFailed
+	}
+
+	return &resumed, nil
+}
+
+// VerifyOTP completes a debit waiting for DebitStepRequiresOTP with the OTP the
+// customer received. The OTP is verified under a new partner reference number,
+// referring to the debit by its own. A wrong OTP returns the *ResponseError of
+// Ayoconnect, the result can then be verified again.
+func (f *DebitFlow) VerifyOTP(ctx context.Context, result *DebitResult, otp string) (*DebitResult, error) {
+	if result.Step != DebitStepRequiresOTP {
+		return nil, fmt.Errorf("%w: %s debit cannot verify an OTP", ErrUnexpectedDebitStep, result.Step)
+	}
+
+	referenceNo := result.ReferenceNo
+	if referenceNo == "" {
+		var err error
+		referenceNo, err = f.referenceNo(ctx, result, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	b2b2cToken, err := f.customerToken(ctx, result.Card)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := f.Client.VerifyOTP(ctx, &VerifyOTPRequest{
+		PartnerReferenceNo:         f.newID(),
+		OriginalPartnerReferenceNo: result.PartnerReferenceNo,
+		OriginalReferenceNo:        referenceNo,
+		Action:                     OTPActionPayment,
+		OTP:                        otp,
+		AdditionalInfo: VerifyOTPRequestAdditionalInfo{
+			PublicUserID: result.Card.PublicUserID,
+			AccountToken: result.Card.AccountToken,
+			BankCode:     result.Card.BankCode,
+		},
+	}, "", b2b2cToken, f.newID())
+	if err != nil {
+		f.rejectCustomerToken(ctx, result.Card, err)
+		return nil, err
+	}
+
+	verified := *result
+	verified.ReferenceNo = referenceNo
+	verified.Step = DebitStepPending
+	if resp.ResponseCode.HTTPCode() == http.StatusOK {
+		verified.Step = DebitStepCompleted
+	}
+
+	return &verified, nil
+}
+
+func (f *DebitFlow) customerToken(ctx context.Context, card DebitCard) (string, error) {
+	return f.Client.CustomerAccessToken(ctx, CustomerTokenKey{
+		PublicUserID: card.PublicUserID,
+		AccountToken: card.AccountToken,
+	}, card.AuthCode)
+}
+
+// rejectCustomerToken deletes the token stored for card when err rejects it.
+func (f *DebitFlow) rejectCustomerToken(ctx context.Context, card DebitCard, err error) {
+	if isCustomerTokenRejected(err) {
+		_ = f.Client.InvalidateCustomerAccessToken(ctx, CustomerTokenKey{
+			PublicUserID: card.PublicUserID,
+			AccountToken: card.AccountToken,
+		})
+	}
+}
+
+// referenceNo returns the reference Ayoconnect assigned to the debit of result. It is
+// read from the body of debitErr when it has one, and queried with DebitStatus
+// otherwise.
+func (f *DebitFlow) referenceNo(ctx context.Context, result *DebitResult, debitErr error) (string, error) {
+	var respErr *ResponseError
+	if errors.As(debitErr, &respErr) {
+		var body struct {
+			ReferenceNo string `json:"referenceNo"`
+		}
+		if json.Unmarshal([]byte(respErr.Body), &body) == nil && body.ReferenceNo != "" {
+			return body.ReferenceNo, nil
+		}
+	}
+
+	status, err := f.Client.DebitStatus(ctx, "", result.ExternalID, f.newID())
+	if err != nil {
+		return "", err
+	}
+
+	return status.ReferenceNo, nil
+}
+
+func (f *DebitFlow) newID() string {
+	if f.NewID != nil {
+		return f.NewID()
+	}
+
+	return randomExternalID()
+}
+
+// update sets the step of the result from the response of Debit, or of DebitStatus
+// when SafeDebit reconciled the debit.
+func (r *DebitResult) update(resp *DebitResponse, otpAllowed bool) {
+	r.ReferenceNo = resp.ReferenceNo
+	r.TransactionStatus = resp.LatestTransactionStatus
+
+	switch {
+	case resp.LatestTransactionStatus == TransactionStatusSuccess:
+		r.Step = DebitStepCompleted
+	case slices.Contains(FinalTransactionStatus, resp.LatestTransactionStatus):
+		r.Step = DebitStepFailed
+	case resp.LatestTransactionStatus == TransactionStatusNotFound:
+		// the debit may still show up, it has not failed yet
+		r.Step = DebitStepPending
+	case resp.WebRedirectURL != "":
+		r.Step = DebitStepRequiresRedirect
+		r.RedirectURL = resp.WebRedirectURL
+	case resp.ResponseCode.HTTPCode() == http.StatusAccepted && otpAllowed:
+		r.Step = DebitStepRequiresOTP
+	case resp.ResponseCode.HTTPCode() == http.StatusOK && resp.LatestTransactionStatus == "":
+		r.Step = DebitStepCompleted
+	default:
+		r.Step = DebitStepPending
+	}
+}
+
+// isOTPSentError reports whether a debit failed because Ayoconnect sent an OTP to
+// the cardholder.
+func isOTPSentError(err error) bool {
+	var respErr *ResponseError
+	if !errors.As(err, &respErr) {
+		return false
+	}
+
+	return respErr.ResponseCode.HTTPCode() == http.StatusForbidden && respErr.ResponseCode.CaseCode() == "13"
+}
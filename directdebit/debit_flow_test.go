@@ -0,0 +1,264 @@
+package directdebit_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit"
+)
+
+var _ = Describe("DebitFlow", func() {
+	var (
+		flow         *directdebit.DebitFlow
+		server       *httptest.Server
+		mu           sync.Mutex
+		debitStatus  int
+		debitBody    string
+		statusBody   string
+		otpStatus    int
+		otpBody      string
+		debitRequest directdebit.DebitRequest
+		otpRequest   directdebit.VerifyOTPRequest
+		req          *directdebit.DebitFlowRequest
+	)
+
+	BeforeEach(func() {
+		debitStatus = http.StatusOK
+		debitBody = `{"responseCode": "2005400", "responseMessage": "Successful", "referenceNo": "ref"}`
+		statusBody = `{"responseCode": "2005500", "latestTransactionStatus": "00", "referenceNo": "ref"}`
+		otpStatus = http.StatusOK
+		otpBody = `{"responseCode": "2000400", "responseMessage": "Successful"}`
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			body, _ := io.ReadAll(r.Body)
+
+			switch r.URL.Path {
+			case directdebit.GetBusinessAccessTokenEndpoint:
+				w.Write([]byte(`{"responseCode": "2007300", "accessToken": "b2bToken", "expiresIn": 3599}`))
+			case directdebit.GetCustomerAccessTokenEndpoint:
+				w.Write([]byte(`{"responseCode": "2007400", "accessToken": "b2b2cToken", "expiresIn": 3599}`))
+			case directdebit.DebitEndpoint:
+				json.Unmarshal(body, &debitRequest)
+				Expect(r.Header.Get("Authorization-Customer")).Should(Equal("Bearer b2b2cToken"))
+				w.WriteHeader(debitStatus)
+				w.Write([]byte(debitBody))
+			case directdebit.DebitStatusEndpoint:
+				w.Write([]byte(statusBody))
+			case directdebit.VerifyOTPEndpoint:
+				json.Unmarshal(body, &otpRequest)
+				w.WriteHeader(otpStatus)
+				w.Write([]byte(otpBody))
+			}
+		}))
+
+		client, err := directdebit.New(&directdebit.Config{
+			ClientID:        "123",
+			MerchantID:      "123",
+			EndpointBaseURL: server.URL,
+			HTTPClient:      &http.Client{},
+			Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+			Signer: signerFunc(func(_ context.Context, _ []byte) ([]byte, error) {
+				return []byte("signature"), nil
+			}),
+			ReconcilePolicy: &directdebit.ReconcilePolicy{MaxAttempts: 2, Interval: time.Millisecond},
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		flow = directdebit.NewDebitFlow(client)
+		req = &directdebit.DebitFlowRequest{
+			Card:    directdebit.DebitCard{PublicUserID: "user", AccountToken: "card", AuthCode: "authCode"},
+			Amount:  directdebit.Amount{Value: "10000.00", Currency: "IDR"},
+			Remarks: "order 1",
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	When("the debit succeeds", func() {
+		It("acquires the tokens and completes", func() {
+			result, err := flow.Start(context.Background(), req)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(result.Step).Should(Equal(directdebit.DebitStepCompleted))
+			Expect(result.ReferenceNo).Should(Equal("ref"))
+			Expect(result.PartnerReferenceNo).Should(Equal(debitRequest.PartnerReferenceNo))
+			Expect(debitRequest.BankCardToken).Should(Equal("card"))
+			Expect(debitRequest.AdditionalInfo.PublicUserID).Should(Equal("user"))
+			Expect(debitRequest.AdditionalInfo.OtpAllowed).Should(BeEmpty())
+		})
+	})
+
+	When("the debit requires a redirect", func() {
+		It("returns the redirect URL and resumes from the status", func() {
+			debitStatus = http.StatusAccepted
+			debitBody = `{"responseCode": "2025400", "referenceNo": "ref", "webRedirectUrl": "https://bank.example/approve"}`
+			statusBody = `{"responseCode": "2005500", "latestTransactionStatus": "03"}`
+
+			result, err := flow.Start(context.Background(), req)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(result.Step).Should(Equal(directdebit.DebitStepRequiresRedirect))
+			Expect(result.RedirectURL).Should(Equal("https://bank.example/approve"))
+
+			resumed, err := flow.Resume(context.Background(), result)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(resumed.Step).Should(Equal(directdebit.DebitStepRequiresRedirect))
+
+			statusBody = `{"responseCode": "2005500", "latestTransactionStatus": "06"}`
+			resumed, err = flow.Resume(context.Background(), result)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(resumed.Step).Should(Equal(directdebit.DebitStepFailed))
+			Expect(resumed.TransactionStatus).Should(Equal(directdebit.TransactionStatusFailed))
+		})
+	})
+
+	When("the debit requires an OTP", func() {
+		BeforeEach(func() {
+			req.OTPAllowed = true
+			debitStatus = http.StatusAccepted
+			debitBody = `{"responseCode": "2025400", "referenceNo": "ref"}`
+		})
+
+		It("completes once the OTP is verified", func() {
+			result, err := flow.Start(context.Background(), req)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(result.Step).Should(Equal(directdebit.DebitStepRequiresOTP))
+			Expect(debitRequest.AdditionalInfo.OtpAllowed).Should(Equal("YES"))
+
+			verified, err := flow.VerifyOTP(context.Background(), result, "123456")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(verified.Step).Should(Equal(directdebit.DebitStepCompleted))
+			Expect(otpRequest.Action).Should(Equal(directdebit.OTPActionPayment))
+			Expect(otpRequest.OriginalReferenceNo).Should(Equal("ref"))
+			Expect(otpRequest.OriginalPartnerReferenceNo).Should(Equal(result.PartnerReferenceNo))
+			Expect(otpRequest.PartnerReferenceNo).ShouldNot(BeEmpty())
+			Expect(otpRequest.PartnerReferenceNo).ShouldNot(Equal(result.PartnerReferenceNo))
+			Expect(otpRequest.OTP).Should(Equal("123456"))
+		})
+
+		It("can be verified again after a wrong OTP", func() {
+			result, err := flow.Start(context.Background(), req)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			otpStatus = http.StatusNotFound
+			otpBody = `{"responseCode": "4040415", "responseMessage": "Invalid OTP"}`
+			_, err = flow.VerifyOTP(context.Background(), result, "000000")
+			Expect(err).Should(HaveOccurred())
+			Expect(result.Step).Should(Equal(directdebit.DebitStepRequiresOTP))
+
+			otpStatus = http.StatusOK
+			otpBody = `{"responseCode": "2000400", "responseMessage": "Successful"}`
+			verified, err := flow.VerifyOTP(context.Background(), result, "123456")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(verified.Step).Should(Equal(directdebit.DebitStepCompleted))
+		})
+
+		It("treats OTP Sent To Cardholder as requiring an OTP", func() {
+			debitStatus = http.StatusForbidden
+			debitBody = `{"responseCode": "4035413", "responseMessage": "OTP Sent To Cardholder"}`
+
+			result, err := flow.Start(context.Background(), req)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(result.Step).Should(Equal(directdebit.DebitStepRequiresOTP))
+			Expect(result.ReferenceNo).Should(Equal("ref"))
+
+			_, err = flow.VerifyOTP(context.Background(), result, "123456")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(otpRequest.OriginalReferenceNo).Should(Equal("ref"))
+		})
+
+		It("reads the reference of OTP Sent To Cardholder from its body", func() {
+			debitStatus = http.StatusForbidden
+			debitBody = `{"responseCode": "4035413", "responseMessage": "OTP Sent To Cardholder", "referenceNo": "otpRef"}`
+
+			result, err := flow.Start(context.Background(), req)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(result.ReferenceNo).Should(Equal("otpRef"))
+		})
+
+		It("looks the reference up when verifying a result without one", func() {
+			result, err := flow.Start(context.Background(), req)
+			Expect(err).ShouldNot(HaveOccurred())
+			result.ReferenceNo = ""
+
+			verified, err := flow.VerifyOTP(context.Background(), result, "123456")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(verified.ReferenceNo).Should(Equal("ref"))
+			Expect(otpRequest.OriginalReferenceNo).Should(Equal("ref"))
+		})
+	})
+
+	When("the debit is declined", func() {
+		It("returns the error", func() {
+			debitStatus = http.StatusForbidden
+			debitBody = `{"responseCode": "4035414", "responseMessage": "Insufficient Funds"}`
+
+			_, err := flow.Start(context.Background(), req)
+			Expect(err).Should(MatchError(directdebit.ErrInsufficientFunds))
+		})
+	})
+
+	When("the customer token is rejected", func() {
+		It("deletes the stored token", func() {
+			debitStatus = http.StatusUnauthorized
+			debitBody = `{"responseCode": "4015402", "responseMessage": "Invalid Customer Token"}`
+
+			_, err := flow.Start(context.Background(), req)
+			Expect(err).Should(MatchError(directdebit.ErrAuthFailure))
+
+			_, err = flow.Client.CustomerAccessToken(context.Background(), directdebit.CustomerTokenKey{PublicUserID: "user", AccountToken: "card"}, "")
+			Expect(err).Should(MatchError(directdebit.ErrCustomerTokenNotFound))
+		})
+	})
+
+	When("the debit outcome is unknown", func() {
+		It("returns a pending result that can be resumed", func() {
+			debitStatus = http.StatusGatewayTimeout
+			debitBody = `{"responseCode": "5045400", "responseMessage": "Timeout"}`
+			statusBody = `{"responseCode": "2005500", "latestTransactionStatus": "03"}`
+
+			result, err := flow.Start(context.Background(), req)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(result.Step).Should(Equal(directdebit.DebitStepPending))
+
+			statusBody = `{"responseCode": "2005500", "latestTransactionStatus": "00", "referenceNo": "ref"}`
+			resumed, err := flow.Resume(context.Background(), result)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(resumed.Step).Should(Equal(directdebit.DebitStepCompleted))
+			Expect(resumed.ReferenceNo).Should(Equal("ref"))
+		})
+
+		It("stays pending while the debit is not found", func() {
+			debitStatus = http.StatusGatewayTimeout
+			debitBody = `{"responseCode": "5045400", "responseMessage": "Timeout"}`
+			statusBody = `{"responseCode": "2005500", "latestTransactionStatus": "07"}`
+
+			result, err := flow.Start(context.Background(), req)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(result.Step).Should(Equal(directdebit.DebitStepPending))
+
+			resumed, err := flow.Resume(context.Background(), result)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(resumed.Step).Should(Equal(directdebit.DebitStepPending))
+		})
+	})
+
+	When("an OTP is verified for a debit that does not wait for one", func() {
+		It("returns ErrUnexpectedDebitStep", func() {
+			_, err := flow.VerifyOTP(context.Background(), &directdebit.DebitResult{Step: directdebit.DebitStepCompleted}, "123456")
+			Expect(err).Should(MatchError(directdebit.ErrUnexpectedDebitStep))
+		})
+	})
+})
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
-	"time"
 )
 
 func (c Client) DebitStatus(
@@ -13,14 +12,19 @@ func (c Client) DebitStatus(
 	debitTxExternalID,
 	externalID string,
 ) (*DebitResponse, error) {
-	timestamp := time.Now().Format(time.RFC3339)
-
-	signature := generateHmacSignature("GET", DebitStatusEndpoint, b2bToken, "", timestamp, c.Config.ClientSecret)
-	headers := c.BuildHeader(timestamp, signature, b2bToken, "", externalID)
+	b2bToken, err := c.resolveB2BToken(ctx, b2bToken)
+	if err != nil {
+		return nil, err
+	}
 
 	endpoint := DebitStatusEndpoint + "?XExternalId=" + debitTxExternalID + "&merchantId=" + c.Config.MerchantID
 
-	resp, err := c.Execute(ctx, http.MethodGet, endpoint, headers, nil)
+	resp, err := c.send(ctx, &call{
+		operation: OperationDebitStatus,
+		method:    http.MethodGet,
+		path:      endpoint,
+		sign:      c.hmacHeaders(http.MethodGet, DebitStatusEndpoint, nil, b2bToken, "", externalID),
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,97 @@
+package directdebit
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// DebugDump describes a single attempt exactly as it was signed and sent, and the
+// raw response received for it. It is emitted when Config.Debug or
+// Config.DebugHook is set.
+type DebugDump struct {
+	Operation string
+	Method    string
+	Path      string
+	// StringToSign is the string the X-SIGNATURE header was computed from. Access
+	// token requests sign "clientID|timestamp" with RSA, the other requests sign
+	// "METHOD:path:accessToken:bodyHash:timestamp" with HMAC-SHA512.
+	StringToSign string
+	// BodyHash is the lowercase hex SHA-256 of RequestBody.
+	BodyHash string
+	// Header holds the headers produced by BuildHeader.
+	Header RequestHeader
+	// HTTPHeader holds every header set on the HTTP request, including
+	// Request.ExtraHeader.
+	HTTPHeader  http.Header
+	RequestBody []byte
+	Attempt     int
+	// StatusCode, ResponseHeader and ResponseBody are empty when no response was
+	// received.
+	StatusCode     int
+	ResponseHeader http.Header
+	ResponseBody   []byte
+	Err            error
+}
+
+func newDebugDump(r *Request) *DebugDump {
+	return &DebugDump{
+		Operation:    r.Operation,
+		Method:       r.Method,
+		Path:         r.Path,
+		StringToSign: r.StringToSign,
+		BodyHash:     bodyHash(r.Body),
+		Header:       r.Header,
+		RequestBody:  r.Body,
+		Attempt:      r.Attempt,
+	}
+}
+
+// debug hands dump to Config.DebugHook and logs it when Config.Debug is set.
+func (c Client) debug(ctx context.Context, dump *DebugDump) {
+	if c.Config.DebugHook != nil {
+		c.Config.DebugHook(ctx, dump)
+	}
+
+	if !c.Config.Debug || c.Config.Logger == nil {
+		return
+	}
+
+	redactor := c.redactor()
+	attrs := []slog.Attr{
+		slog.String("operation", dump.Operation),
+		slog.String("method", dump.Method),
+		slog.String("path", redactor.RedactPath(dump.Path)),
+		slog.String("string_to_sign", redactStringToSign(redactor, dump)),
+		slog.String("body_hash", dump.BodyHash),
+		slog.Any("request_headers", redactor.RedactHeader(dump.HTTPHeader)),
+		slog.String("request_body", redactor.RedactJSON(dump.RequestBody)),
+		slog.Int("attempt", dump.Attempt),
+	}
+
+	if dump.StatusCode != 0 {
+		attrs = append(attrs,
+			slog.Int("response_status", dump.StatusCode),
+			slog.Any("response_headers", redactor.RedactHeader(dump.ResponseHeader)),
+			slog.String("response_body", redactor.RedactJSON(dump.ResponseBody)),
+		)
+	}
+
+	if dump.Err != nil {
+		attrs = append(attrs, redactor.errorAttr(dump.Err))
+	}
+
+	c.Config.Logger.LogAttrs(ctx, slog.LevelDebug, "ayoconnect request", attrs...)
+}
+
+// redactStringToSign masks the access token signed by HMAC requests, the other
+// segments of the string are not secret.
+func redactStringToSign(redactor *Redactor, dump *DebugDump) string {
+	token := strings.TrimPrefix(dump.Header.Authorization, "Bearer ")
+	if token == "" {
+		return dump.StringToSign
+	}
+
+	return strings.ReplaceAll(dump.StringToSign, token, redactor.mask())
+}
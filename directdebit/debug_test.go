@@ -0,0 +1,156 @@
+package directdebit_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit"
+)
+
+func TestDebugHook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "requestID")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"responseCode": "2005500", "responseMessage": "Successful"}`))
+	}))
+	defer server.Close()
+
+	var dumps []*directdebit.DebugDump
+	client, err := directdebit.New(&directdebit.Config{
+		ClientID:        "123",
+		ClientSecret:    "secret",
+		MerchantID:      "123",
+		EndpointBaseURL: server.URL,
+		HTTPClient:      &http.Client{},
+		DebugHook: func(_ context.Context, dump *directdebit.DebugDump) {
+			dumps = append(dumps, dump)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	if _, err := client.DebitStatus(context.Background(), "b2bToken", "debitExternalID", "externalID"); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	if len(dumps) != 1 {
+		t.Fatalf("Expected 1 dump, got %d", len(dumps))
+	}
+
+	dump := dumps[0]
+	hash := fmt.Sprintf("%x", sha256.Sum256(dump.RequestBody))
+	if dump.BodyHash != hash {
+		t.Errorf("Expected body hash %s, got %s", hash, dump.BodyHash)
+	}
+
+	// the query string is not signed
+	stringToSign := "GET:" + directdebit.DebitStatusEndpoint + ":b2bToken:" + hash + ":" + dump.Header.Timestamp
+	if dump.StringToSign != stringToSign {
+		t.Errorf("Expected string to sign %s, got %s", stringToSign, dump.StringToSign)
+	}
+
+	err = directdebit.VerifyHmacSignature(dump.Header.Signature, http.MethodGet, directdebit.DebitStatusEndpoint, "b2bToken",
+		string(dump.RequestBody), dump.Header.Timestamp, "secret", 0)
+	if err != nil {
+		t.Errorf("Expected the dumped signature to verify, but got: %v", err)
+	}
+
+	if dump.Operation != directdebit.OperationDebitStatus || dump.Attempt != 1 {
+		t.Errorf("Unexpected operation %s attempt %d", dump.Operation, dump.Attempt)
+	}
+
+	if dump.HTTPHeader.Get("X-EXTERNAL-ID") != "externalID" {
+		t.Errorf("Expected the sent headers, got %v", dump.HTTPHeader)
+	}
+
+	if dump.StatusCode != http.StatusOK || dump.ResponseHeader.Get("X-Request-Id") != "requestID" {
+		t.Errorf("Expected the response to be dumped, got %d %v", dump.StatusCode, dump.ResponseHeader)
+	}
+
+	if !strings.Contains(string(dump.ResponseBody), "2005500") {
+		t.Errorf("Expected the raw response body, got %s", dump.ResponseBody)
+	}
+}
+
+func TestDebugLogsRedactedDump(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"responseCode": "2007400", "accessToken": "b2b2cToken"}`))
+	}))
+	defer server.Close()
+
+	var logs bytes.Buffer
+	client, err := directdebit.New(&directdebit.Config{
+		ClientID:        "123",
+		ClientSecret:    "secret",
+		MerchantID:      "123",
+		EndpointBaseURL: server.URL,
+		HTTPClient:      &http.Client{},
+		Logger:          slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		Debug:           true,
+	})
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	if _, err := client.DebitStatus(context.Background(), "b2bToken", "debitExternalID", "externalID"); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	for _, want := range []string{`"string_to_sign":"GET:/api/v1.0/debit/status:[REDACTED]:`, `"body_hash":`, `"response_status":200`} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("Expected %s to be logged, got %s", want, logs.String())
+		}
+	}
+
+	for _, secret := range []string{"b2bToken", "b2b2cToken"} {
+		if strings.Contains(logs.String(), secret) {
+			t.Errorf("Expected %s to be redacted from %s", secret, logs.String())
+		}
+	}
+}
+
+func TestDebugLogRedactsTransportErrors(t *testing.T) {
+	var logs bytes.Buffer
+	client, err := directdebit.New(&directdebit.Config{
+		ClientID:        "123",
+		ClientSecret:    "secret",
+		MerchantID:      "123",
+		EndpointBaseURL: "http://ayoconnect.test",
+		HTTPClient: &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			return nil, errors.New("connection reset")
+		})},
+		Logger:      slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		RetryPolicy: &directdebit.RetryPolicy{MaxAttempts: 1},
+		Debug:       true,
+	})
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	req := &directdebit.GetAuthCodeRequest{
+		State:        "state",
+		RedirectURL:  "https://merchant.example/callback",
+		SeamlessData: directdebit.SeamlessData{MobileNumber: "081234567890", BankCode: "CENAIDJA"},
+	}
+	if _, err := client.GetAuthCode(context.Background(), req, "b2bToken", "externalID"); err == nil {
+		t.Fatal("Expected an error, but got nil")
+	}
+
+	if !strings.Contains(logs.String(), "connection reset") {
+		t.Errorf("Expected the error to be logged, got %s", logs.String())
+	}
+
+	if strings.Contains(logs.String(), "081234567890") {
+		t.Errorf("Expected the mobile number to be redacted from %s", logs.String())
+	}
+}
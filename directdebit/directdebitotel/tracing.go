@@ -0,0 +1,173 @@
+// Package directdebitotel traces requests sent by the directdebit client with
+// OpenTelemetry.
+//
+// Add the middleware to the client configuration:
+//
+//	cfg.Middlewares = append(cfg.Middlewares, directdebitotel.Middleware())
+package directdebitotel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit"
+)
+
+const instrumentationName = "github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit/directdebitotel"
+
+// Attribute keys of Ayoconnect specific span attributes.
+const (
+	OperationKey          = attribute.Key("ayoconnect.operation")
+	AttemptKey            = attribute.Key("ayoconnect.attempt")
+	ExternalIDKey         = attribute.Key("ayoconnect.external_id")
+	PartnerReferenceNoKey = attribute.Key("ayoconnect.partner_reference_no")
+	ResponseCodeKey       = attribute.Key("ayoconnect.response_code")
+)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	propagators    propagation.TextMapPropagator
+	redactor       *directdebit.Redactor
+}
+
+// Option configures the tracing middleware.
+type Option func(*config)
+
+// WithTracerProvider sets the provider spans are created with. Defaults to the
+// global provider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = tp
+	}
+}
+
+// WithPropagators sets the propagators that inject the span context into outgoing
+// requests. Defaults to W3C Trace Context.
+func WithPropagators(p propagation.TextMapPropagator) Option {
+	return func(c *config) {
+		c.propagators = p
+	}
+}
+
+// WithRedactor sets the redactor applied to errors recorded on spans. Defaults to
+// directdebit.DefaultRedactor, pass Config.Redactor when the client uses its own. A
+// nil redactor keeps the default.
+func WithRedactor(r *directdebit.Redactor) Option {
+	return func(c *config) {
+		if r != nil {
+			c.redactor = r
+		}
+	}
+}
+
+// Middleware returns a directdebit.Middleware that starts a client span for every
+// request attempt, as a child of the span in the request context, and propagates it
+// to Ayoconnect in the request headers.
+func Middleware(opts ...Option) directdebit.Middleware {
+	cfg := config{
+		tracerProvider: otel.GetTracerProvider(),
+		propagators:    propagation.TraceContext{},
+		redactor:       directdebit.DefaultRedactor(),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	tracer := cfg.tracerProvider.Tracer(instrumentationName)
+
+	return func(next directdebit.Handler) directdebit.Handler {
+		return func(ctx context.Context, req *directdebit.Request) (*directdebit.Response, error) {
+			endpoint, _, _ := strings.Cut(req.Path, "?")
+
+			name := req.Operation
+			if name == "" {
+				name = endpoint
+			}
+
+			attrs := []attribute.KeyValue{
+				OperationKey.String(req.Operation),
+				AttemptKey.Int(req.Attempt),
+				semconv.HTTPRequestMethodKey.String(req.Method),
+				semconv.URLPath(endpoint),
+			}
+			if req.Header.ExternalID != "" {
+				attrs = append(attrs, ExternalIDKey.String(req.Header.ExternalID))
+			}
+			if ref := partnerReferenceNo(req.Body); ref != "" {
+				attrs = append(attrs, PartnerReferenceNoKey.String(ref))
+			}
+
+			ctx, span := tracer.Start(ctx, "Ayoconnect "+name,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(attrs...),
+			)
+			defer span.End()
+
+			if req.ExtraHeader == nil {
+				req.ExtraHeader = make(map[string][]string)
+			}
+			cfg.propagators.Inject(ctx, propagation.HeaderCarrier(req.ExtraHeader))
+
+			resp, err := next(ctx, req)
+			if err != nil {
+				var respErr *directdebit.ResponseError
+				if errors.As(err, &respErr) {
+					span.SetAttributes(
+						semconv.HTTPResponseStatusCode(respErr.StatusCode),
+						ResponseCodeKey.String(string(respErr.ResponseCode)),
+					)
+				}
+				msg := cfg.redactor.RedactError(err)
+				span.RecordError(errors.New(msg))
+				span.SetStatus(codes.Error, msg)
+
+				return nil, err
+			}
+
+			span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+			if code := responseCode(resp.Body); code != "" {
+				span.SetAttributes(ResponseCodeKey.String(code))
+			}
+
+			return resp, nil
+		}
+	}
+}
+
+// partnerReferenceNo returns the partner reference number of a request body. Requests
+// about an earlier transaction carry it as originalPartnerReferenceNo.
+func partnerReferenceNo(body []byte) string {
+	var fields struct {
+		PartnerReferenceNo         string `json:"partnerReferenceNo"`
+		OriginalPartnerReferenceNo string `json:"originalPartnerReferenceNo"`
+	}
+	if len(body) == 0 || json.Unmarshal(body, &fields) != nil {
+		return ""
+	}
+
+	if fields.PartnerReferenceNo != "" {
+		return fields.PartnerReferenceNo
+	}
+
+	return fields.OriginalPartnerReferenceNo
+}
+
+func responseCode(body []byte) string {
+	var fields struct {
+		ResponseCode string `json:"responseCode"`
+	}
+	if json.Unmarshal(body, &fields) != nil {
+		return ""
+	}
+
+	return fields.ResponseCode
+}
@@ -0,0 +1,190 @@
+package directdebitotel_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit"
+	"github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit/directdebitotel"
+)
+
+func newClient(t *testing.T, status int, body string) (*directdebit.Client, *tracetest.SpanRecorder, *http.Header) {
+	t.Helper()
+
+	gotHeader := &http.Header{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*gotHeader = r.Header.Clone()
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	client, err := directdebit.New(&directdebit.Config{
+		ClientID:        "123",
+		MerchantID:      "123",
+		EndpointBaseURL: server.URL,
+		HTTPClient:      &http.Client{},
+		Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Middlewares: []directdebit.Middleware{
+			directdebitotel.Middleware(directdebitotel.WithTracerProvider(provider)),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	return client, recorder, gotHeader
+}
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+
+	return attrs
+}
+
+func TestMiddlewareRecordsSpan(t *testing.T) {
+	client, recorder, gotHeader := newClient(t, http.StatusOK, `{"responseCode": "2005400", "responseMessage": "Successful"}`)
+
+	parentProvider := sdktrace.NewTracerProvider()
+	ctx, parent := parentProvider.Tracer("test").Start(context.Background(), "checkout")
+	defer parent.End()
+
+	req := &directdebit.DebitRequest{PartnerReferenceNo: "ref"}
+	if _, err := client.Debit(ctx, req, "b2bToken", "b2b2cToken", "externalID"); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+
+	if span.Name() != "Ayoconnect Debit" {
+		t.Errorf("Expected span name %q, got %q", "Ayoconnect Debit", span.Name())
+	}
+
+	if span.SpanKind() != trace.SpanKindClient {
+		t.Errorf("Expected a client span, got %v", span.SpanKind())
+	}
+
+	if span.Parent().TraceID() != parent.SpanContext().TraceID() {
+		t.Errorf("Expected the span to be a child of the context span")
+	}
+
+	attrs := attributes(span)
+	expected := map[attribute.Key]attribute.Value{
+		directdebitotel.OperationKey:          attribute.StringValue(directdebit.OperationDebit),
+		directdebitotel.ExternalIDKey:         attribute.StringValue("externalID"),
+		directdebitotel.PartnerReferenceNoKey: attribute.StringValue("ref"),
+		directdebitotel.ResponseCodeKey:       attribute.StringValue("2005400"),
+		"url.path":                            attribute.StringValue(directdebit.DebitEndpoint),
+		"http.request.method":                 attribute.StringValue(http.MethodPost),
+		"http.response.status_code":           attribute.IntValue(http.StatusOK),
+	}
+	for key, want := range expected {
+		if got := attrs[key]; got != want {
+			t.Errorf("Expected attribute %s to be %v, got %v", key, want.Emit(), got.Emit())
+		}
+	}
+
+	if gotHeader.Get("Traceparent") == "" {
+		t.Errorf("Expected the trace context to be propagated")
+	}
+}
+
+func TestMiddlewareRecordsError(t *testing.T) {
+	client, recorder, _ := newClient(t, http.StatusForbidden, `{"responseCode": "4035414", "responseMessage": "Insufficient Funds"}`)
+
+	_, err := client.DebitStatus(context.Background(), "b2bToken", "debitExternalID", "externalID")
+	if err == nil {
+		t.Fatalf("Expected an error")
+	}
+
+	span := recorder.Ended()[0]
+	if span.Status().Code != codes.Error {
+		t.Errorf("Expected an error status, got %v", span.Status().Code)
+	}
+
+	attrs := attributes(span)
+	if got := attrs[directdebitotel.ResponseCodeKey].AsString(); got != "4035414" {
+		t.Errorf("Expected response code %q, got %q", "4035414", got)
+	}
+
+	if got := attrs["http.response.status_code"].AsInt64(); got != http.StatusForbidden {
+		t.Errorf("Expected status %d, got %d", http.StatusForbidden, got)
+	}
+
+	if got := attrs["url.path"].AsString(); got != directdebit.DebitStatusEndpoint {
+		t.Errorf("Expected path %q without query, got %q", directdebit.DebitStatusEndpoint, got)
+	}
+}
+
+func TestMiddlewareRedactsTransportErrors(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	client, err := directdebit.New(&directdebit.Config{
+		ClientID:        "123",
+		MerchantID:      "123",
+		EndpointBaseURL: "http://ayoconnect.test",
+		HTTPClient: &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			return nil, errors.New("connection reset")
+		})},
+		Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		RetryPolicy: &directdebit.RetryPolicy{MaxAttempts: 1},
+		Middlewares: []directdebit.Middleware{
+			directdebitotel.Middleware(directdebitotel.WithTracerProvider(provider)),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	req := &directdebit.GetAuthCodeRequest{
+		State:        "state",
+		SeamlessData: directdebit.SeamlessData{MobileNumber: "081234567890", BankCode: "CENAIDJA"},
+	}
+	if _, err := client.GetAuthCode(context.Background(), req, "b2bToken", "externalID"); err == nil {
+		t.Fatalf("Expected an error")
+	}
+
+	span := recorder.Ended()[0]
+	if !strings.Contains(span.Status().Description, "connection reset") {
+		t.Errorf("Expected the error in the status, got %q", span.Status().Description)
+	}
+
+	recorded := span.Status().Description
+	for _, event := range span.Events() {
+		for _, kv := range event.Attributes {
+			recorded += " " + kv.Value.Emit()
+		}
+	}
+	if strings.Contains(recorded, "081234567890") {
+		t.Errorf("Expected the mobile number to be redacted from %q", recorded)
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
@@ -0,0 +1,121 @@
+// Package directdebitprom exports measurements of the directdebit client as
+// Prometheus metrics.
+//
+// Register the metrics and set them on the client configuration:
+//
+//	metrics, err := directdebitprom.NewMetrics(prometheus.DefaultRegisterer)
+//	cfg.Metrics = metrics
+package directdebitprom
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit"
+)
+
+// DefaultBuckets are the request duration histogram buckets in seconds.
+var DefaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Metrics implements directdebit.Metrics with Prometheus collectors.
+type Metrics struct {
+	requestDuration *prometheus.HistogramVec
+	responses       *prometheus.CounterVec
+	retries         *prometheus.CounterVec
+	tokenRefreshes  *prometheus.CounterVec
+}
+
+// Option configures Metrics.
+type Option func(*options)
+
+type options struct {
+	namespace string
+	buckets   []float64
+}
+
+// WithNamespace prefixes the metric names. Defaults to "ayoconnect".
+func WithNamespace(namespace string) Option {
+	return func(o *options) {
+		o.namespace = namespace
+	}
+}
+
+// WithBuckets sets the request duration histogram buckets. Defaults to DefaultBuckets.
+func WithBuckets(buckets []float64) Option {
+	return func(o *options) {
+		o.buckets = buckets
+	}
+}
+
+// NewMetrics creates the collectors and registers them with reg:
+//
+//   - <namespace>_request_duration_seconds, a histogram by operation, endpoint and status
+//   - <namespace>_responses_total, a counter by operation and response_code
+//   - <namespace>_retries_total, a counter by operation
+//   - <namespace>_token_refreshes_total, a counter by kind and result
+func NewMetrics(reg prometheus.Registerer, opts ...Option) (*Metrics, error) {
+	o := options{namespace: "ayoconnect", buckets: DefaultBuckets}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	m := &Metrics{
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: o.namespace,
+			Name:      "request_duration_seconds",
+			Help:      "Duration of requests to Ayoconnect, per attempt.",
+			Buckets:   o.buckets,
+		}, []string{"operation", "endpoint", "status"}),
+		responses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.namespace,
+			Name:      "responses_total",
+			Help:      "Responses received from Ayoconnect by SNAP response code.",
+		}, []string{"operation", "response_code"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.namespace,
+			Name:      "retries_total",
+			Help:      "Requests to Ayoconnect that were attempted again.",
+		}, []string{"operation"}),
+		tokenRefreshes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.namespace,
+			Name:      "token_refreshes_total",
+			Help:      "Access token requests to Ayoconnect.",
+		}, []string{"kind", "result"}),
+	}
+
+	for _, c := range []prometheus.Collector{m.requestDuration, m.responses, m.retries, m.tokenRefreshes} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+func (m *Metrics) ObserveRequest(_ context.Context, obs directdebit.RequestObservation) {
+	status := "error"
+	if obs.StatusCode != 0 {
+		status = strconv.Itoa(obs.StatusCode)
+	}
+
+	m.requestDuration.WithLabelValues(obs.Operation, obs.Endpoint, status).Observe(obs.Duration.Seconds())
+
+	if obs.ResponseCode != "" {
+		m.responses.WithLabelValues(obs.Operation, string(obs.ResponseCode)).Inc()
+	}
+}
+
+func (m *Metrics) ObserveRetry(_ context.Context, operation string, _ int) {
+	m.retries.WithLabelValues(operation).Inc()
+}
+
+func (m *Metrics) ObserveTokenRefresh(_ context.Context, kind string, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+
+	m.tokenRefreshes.WithLabelValues(kind, result).Inc()
+}
@@ -0,0 +1,109 @@
+package directdebittest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit"
+	"github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit/directdebittest"
+)
+
+func TestRecorder(t *testing.T) {
+	srv := directdebittest.NewServer()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	rec, err := directdebittest.NewRecorder(path, directdebittest.ModeAuto,
+		directdebittest.WithTransport(srv.Config().HTTPClient.Transport))
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	if rec.Mode() != directdebittest.ModeRecord {
+		t.Fatalf("Expected a missing cassette to be recorded")
+	}
+
+	cfg := srv.Config()
+	cfg.HTTPClient = &http.Client{Transport: rec}
+	client, err := directdebit.New(cfg)
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	authCode, err := client.GetAuthCode(context.Background(), &directdebit.GetAuthCodeRequest{
+		SeamlessData: directdebit.SeamlessData{MobileNumber: "081234567890"},
+	}, "", "externalID")
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	_, err = client.DebitStatus(context.Background(), "", "unknown", "externalID")
+	if err == nil {
+		t.Fatalf("Expected an error for an unknown debit")
+	}
+
+	if err := rec.Save(); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	srv.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	for _, secret := range []string{authCode.AuthCode, "081234567890", "Bearer "} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("Expected %s to be redacted from the cassette", secret)
+		}
+	}
+
+	replay, err := directdebittest.NewRecorder(path, directdebittest.ModeAuto)
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	if replay.Mode() != directdebittest.ModeReplay {
+		t.Fatalf("Expected an existing cassette to be replayed")
+	}
+
+	cfg.HTTPClient = &http.Client{Transport: replay}
+	client, err = directdebit.New(cfg)
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	replayed, err := client.GetAuthCode(context.Background(), &directdebit.GetAuthCodeRequest{
+		SeamlessData: directdebit.SeamlessData{MobileNumber: "089999999999"},
+	}, "", "otherExternalID")
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	if replayed.ResponseCode != "2001000" || replayed.AuthCode != directdebit.DefaultRedactionMask {
+		t.Errorf("Unexpected replayed response %+v", replayed)
+	}
+
+	_, err = client.DebitStatus(context.Background(), "", "unknown", "externalID")
+
+	var respErr *directdebit.ResponseError
+	if !errors.As(err, &respErr) || respErr.ResponseCode != "4045501" {
+		t.Errorf("Expected the recorded failure to be replayed, got %v", err)
+	}
+
+	_, err = client.DebitStatus(context.Background(), "", "unknown", "externalID")
+	if !errors.Is(err, directdebittest.ErrNoInteraction) {
+		t.Errorf("Expected every interaction to be replayed once, got %v", err)
+	}
+}
+
+func TestRecorderReplayMissingCassette(t *testing.T) {
+	_, err := directdebittest.NewRecorder(filepath.Join(t.TempDir(), "missing.json"), directdebittest.ModeReplay)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a missing cassette error, got %v", err)
+	}
+}
@@ -0,0 +1,818 @@
+package directdebittest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit"
+)
+
+// Token statuses of a Card.
+const (
+	TokenStatusActive   = "ACTIVE"
+	TokenStatusInactive = "INACTIVE"
+)
+
+// accessTokenTTL is the lifetime in seconds reported for issued access tokens.
+const accessTokenTTL = 3600
+
+var transactionStatusDesc = map[string]string{
+	directdebit.TransactionStatusSuccess:   "Success",
+	directdebit.TransactionStatusInitiated: "Initiated",
+	directdebit.TransactionStatusPaying:    "Paying",
+	directdebit.TransactionStatusPending:   "Pending",
+	directdebit.TransactionStatusRefunded:  "Refunded",
+	directdebit.TransactionStatusCanceled:  "Canceled",
+	directdebit.TransactionStatusFailed:    "Failed",
+	directdebit.TransactionStatusNotFound:  "Not Found",
+}
+
+// authKind is how requests to a route are signed.
+type authKind int
+
+const (
+	authRSA authKind = iota
+	authHMAC
+)
+
+type route struct {
+	operation string
+	// service is the SNAP service code used in response codes.
+	service string
+	auth    authKind
+	// customer routes also require a B2B2C access token.
+	customer bool
+	handle   func(s *Server, r *request) (any, error)
+}
+
+var routes = map[string]route{
+	http.MethodPost + " " + directdebit.GetBusinessAccessTokenEndpoint: {
+		operation: directdebit.OperationGetBusinessAccessToken,
+		service:   "73",
+		auth:      authRSA,
+		handle:    (*Server).businessAccessToken,
+	},
+	http.MethodPost + " " + directdebit.GetCustomerAccessTokenEndpoint: {
+		operation: directdebit.OperationGetCustomerAccessToken,
+		service:   "74",
+		auth:      authRSA,
+		handle:    (*Server).customerAccessToken,
+	},
+	http.MethodGet + " " + directdebit.GetAuthCodeEndpoint: {
+		operation: directdebit.OperationGetAuthCode,
+		service:   "10",
+		auth:      authHMAC,
+		handle:    (*Server).authCode,
+	},
+	http.MethodPost + " " + directdebit.AccountBindingEndpoint: {
+		operation: directdebit.OperationAccountBinding,
+		service:   "07",
+		auth:      authHMAC,
+		handle:    (*Server).accountBinding,
+	},
+	http.MethodPost + " " + directdebit.DebitEndpoint: {
+		operation: directdebit.OperationDebit,
+		service:   "54",
+		auth:      authHMAC,
+		customer:  true,
+		handle:    (*Server).debit,
+	},
+	http.MethodGet + " " + directdebit.DebitStatusEndpoint: {
+		operation: directdebit.OperationDebitStatus,
+		service:   "55",
+		auth:      authHMAC,
+		handle:    (*Server).debitStatus,
+	},
+	http.MethodPost + " " + directdebit.UnbindEndpoint: {
+		operation: directdebit.OperationUnbind,
+		service:   "09",
+		auth:      authHMAC,
+		handle:    (*Server).unbind,
+	},
+	http.MethodPost + " " + directdebit.VerifyOTPEndpoint: {
+		operation: directdebit.OperationVerifyOTP,
+		service:   "04",
+		auth:      authHMAC,
+		customer:  true,
+		handle:    (*Server).verifyOTP,
+	},
+	http.MethodPost + " " + directdebit.GetCardListEndpoint: {
+		operation: directdebit.OperationGetCardList,
+		service:   "01",
+		auth:      authHMAC,
+		handle:    (*Server).cardList,
+	},
+	http.MethodPost + " " + directdebit.RefundEndpoint: {
+		operation: directdebit.OperationRefund,
+		service:   "58",
+		auth:      authHMAC,
+		handle:    (*Server).refund,
+	},
+	http.MethodGet + " " + directdebit.RefundStatusEndpoint: {
+		operation: directdebit.OperationRefundStatus,
+		service:   "58",
+		auth:      authHMAC,
+		handle:    (*Server).refundStatus,
+	},
+	http.MethodPost + " " + directdebit.CancelDebitEndpoint: {
+		operation: directdebit.OperationCancelDebit,
+		service:   "57",
+		auth:      authHMAC,
+		handle:    (*Server).cancelDebit,
+	},
+}
+
+// request is an incoming request and what authentication learnt about it.
+type request struct {
+	*http.Request
+	route route
+	body  []byte
+	// publicUserID is the owner of the B2B2C access token of customer routes.
+	publicUserID string
+}
+
+// snapError is a failed response.
+type snapError struct {
+	code    directdebit.ResponseCode
+	message string
+}
+
+func (e *snapError) Error() string {
+	return string(e.code) + " " + e.message
+}
+
+// fail returns the error of the route for status and caseCode. The message defaults
+// to the SNAP description of the case, and is suffixed with detail when given.
+func (r *request) fail(status int, caseCode string, detail string) error {
+	code := directdebit.ResponseCode(fmt.Sprintf("%03d%s%s", status, r.route.service, caseCode))
+
+	message := code.Description()
+	if detail != "" {
+		message += " " + detail
+	}
+
+	return &snapError{code: code, message: message}
+}
+
+// success returns the response code of a successful request to the route.
+func (r *request) success() directdebit.ResponseCode {
+	return directdebit.ResponseCode("200" + r.route.service + "00")
+}
+
+// accepted is returned by handlers of requests that are still in progress, such as
+// a debit waiting for OTP. It is written with HTTP status 202.
+type accepted struct {
+	body any
+}
+
+// decode unmarshals the JSON body into v.
+func (r *request) decode(v any) error {
+	if err := json.Unmarshal(r.body, v); err != nil {
+		return r.fail(http.StatusBadRequest, "00", "")
+	}
+
+	return nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	rt, ok := routes[r.Method+" "+r.URL.Path]
+	if !ok {
+		writeError(w, &snapError{code: "4050000", message: "Requested Function Is Not Supported"})
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if fault, ok := s.fault(rt.operation); ok {
+		time.Sleep(fault.Delay)
+
+		if fault.ResponseCode != "" {
+			message := fault.ResponseMessage
+			if message == "" {
+				message = fault.ResponseCode.Description()
+			}
+
+			writeError(w, &snapError{code: fault.ResponseCode, message: message})
+			return
+		}
+	}
+
+	req := &request{Request: r, route: rt, body: body}
+
+	s.mu.Lock()
+	resp, err := s.handle(req)
+	s.mu.Unlock()
+
+	if err != nil {
+		var snapErr *snapError
+		if errors.As(err, &snapErr) {
+			writeError(w, snapErr)
+			return
+		}
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusOK
+	if a, ok := resp.(accepted); ok {
+		status = http.StatusAccepted
+		resp = a.body
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func writeError(w http.ResponseWriter, err *snapError) {
+	status := err.code.HTTPCode()
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"responseCode":    string(err.code),
+		"responseMessage": err.message,
+	})
+}
+
+// handle authenticates r and passes it to its route. s.mu must be held.
+func (s *Server) handle(r *request) (any, error) {
+	if err := s.authenticate(r); err != nil {
+		return nil, err
+	}
+
+	return r.route.handle(s, r)
+}
+
+// authenticate checks the SNAP headers of r.
+func (s *Server) authenticate(r *request) error {
+	timestamp := r.Header.Get("X-TIMESTAMP")
+	if timestamp == "" {
+		return r.fail(http.StatusBadRequest, "02", "X-TIMESTAMP")
+	}
+
+	signature := r.Header.Get("X-SIGNATURE")
+	if signature == "" {
+		return r.fail(http.StatusBadRequest, "02", "X-SIGNATURE")
+	}
+
+	if r.Header.Get("X-CLIENT-KEY") != s.ClientID {
+		return r.fail(http.StatusUnauthorized, "00", "Unknown X-CLIENT-KEY")
+	}
+
+	if r.Header.Get("X-PARTNER-ID") != s.MerchantID {
+		return r.fail(http.StatusUnauthorized, "00", "Unknown X-PARTNER-ID")
+	}
+
+	var err error
+	switch r.route.auth {
+	case authRSA:
+		err = directdebit.VerifyRSASignature(signature, timestamp, s.PublicKey, s.ClientID, s.MaxClockSkew)
+	case authHMAC:
+		token := bearerToken(r.Header.Get("Authorization"))
+		if !s.businessTokens[token] {
+			return r.fail(http.StatusUnauthorized, "01", "")
+		}
+
+		err = directdebit.VerifyHmacSignature(signature, r.Method, r.URL.Path, token, string(r.body), timestamp,
+			s.ClientSecret, s.MaxClockSkew)
+	}
+
+	switch {
+	case errors.Is(err, directdebit.ErrInvalidTimestamp):
+		return r.fail(http.StatusBadRequest, "01", "X-TIMESTAMP")
+	case err != nil:
+		return r.fail(http.StatusUnauthorized, "00", "Invalid Signature")
+	}
+
+	if r.route.customer {
+		publicUserID, ok := s.customerTokens[bearerToken(r.Header.Get("Authorization-Customer"))]
+		if !ok {
+			return r.fail(http.StatusUnauthorized, "02", "")
+		}
+		r.publicUserID = publicUserID
+	}
+
+	return nil
+}
+
+func (s *Server) businessAccessToken(r *request) (any, error) {
+	var body directdebit.GetBusinessAccessTokenRequest
+	if err := r.decode(&body); err != nil {
+		return nil, err
+	}
+
+	if body.GrantType != "client_credentials" {
+		return nil, r.fail(http.StatusBadRequest, "01", "grantType")
+	}
+
+	token := randomHex(16)
+	s.businessTokens[token] = true
+
+	return accessTokenResponse(r, token), nil
+}
+
+func (s *Server) customerAccessToken(r *request) (any, error) {
+	if !s.businessTokens[bearerToken(r.Header.Get("Authorization"))] {
+		return nil, r.fail(http.StatusUnauthorized, "01", "")
+	}
+
+	var body directdebit.GetCustomerAccessTokenRequest
+	if err := r.decode(&body); err != nil {
+		return nil, err
+	}
+
+	if body.GrantType != "authorization_code" {
+		return nil, r.fail(http.StatusBadRequest, "01", "grantType")
+	}
+
+	code, ok := s.authCodes[body.AuthCode]
+	if !ok || code.publicUserID == "" {
+		return nil, r.fail(http.StatusUnauthorized, "00", "Invalid authCode")
+	}
+
+	token := randomHex(16)
+	s.customerTokens[token] = code.publicUserID
+
+	return accessTokenResponse(r, token), nil
+}
+
+func accessTokenResponse(r *request, token string) *directdebit.GetAccessTokenResponse {
+	return &directdebit.GetAccessTokenResponse{
+		ResponseCode:    r.success(),
+		ResponseMessage: "Successful",
+		TokenType:       "Bearer",
+		ResponseTime:    time.Now().Format(time.RFC3339),
+		AccessToken:     token,
+		ExpiredIn:       accessTokenTTL,
+	}
+}
+
+func (s *Server) authCode(r *request) (any, error) {
+	query := r.URL.Query()
+	if query.Get("merchantId") != s.MerchantID {
+		return nil, r.fail(http.StatusNotFound, "08", "")
+	}
+
+	var seamless directdebit.SeamlessData
+	if err := json.Unmarshal([]byte(query.Get("seamlessData")), &seamless); err != nil {
+		return nil, r.fail(http.StatusBadRequest, "01", "seamlessData")
+	}
+
+	if seamless.MobileNumber == "" {
+		return nil, r.fail(http.StatusBadRequest, "02", "seamlessData.mobileNumber")
+	}
+
+	code := randomHex(16)
+	s.authCodes[code] = &authCode{mobileNumber: seamless.MobileNumber, bankCode: seamless.BankCode}
+
+	return &directdebit.GetAuthCodeResponse{
+		ResponseCode:    r.success(),
+		ResponseMessage: "Successful",
+		AuthCode:        code,
+		State:           query.Get("state"),
+	}, nil
+}
+
+func (s *Server) accountBinding(r *request) (any, error) {
+	var body directdebit.AccountBindingRequest
+	if err := r.decode(&body); err != nil {
+		return nil, err
+	}
+
+	if err := s.reserveReference(r, body.PartnerReferenceNo, body.MerchantID); err != nil {
+		return nil, err
+	}
+
+	code, ok := s.authCodes[body.AuthCode]
+	if !ok || code.publicUserID != "" {
+		return nil, r.fail(http.StatusUnauthorized, "00", "Invalid authCode")
+	}
+
+	publicUserID, ok := s.users[code.mobileNumber]
+	if !ok {
+		publicUserID = "PUB" + randomDigits(12)
+		s.users[code.mobileNumber] = publicUserID
+	}
+	code.publicUserID = publicUserID
+
+	card := &Card{
+		AccountToken: randomHex(16),
+		PublicUserID: publicUserID,
+		MobileNumber: code.mobileNumber,
+		BankCode:     code.bankCode,
+		MaskedCard:   "526400******" + randomDigits(4),
+		TokenStatus:  TokenStatusActive,
+	}
+	s.cards[card.AccountToken] = card
+
+	return &directdebit.AccountBindingResponse{
+		ResponseCode:       r.success(),
+		ResponseMessage:    "Successful",
+		PartnerReferenceNo: body.PartnerReferenceNo,
+		AccountToken:       card.AccountToken,
+		TokenStatus:        card.TokenStatus,
+		AuthCode:           body.AuthCode,
+		UserInfo:           directdebit.UserInfo{PublicUserID: publicUserID},
+		AdditionalInfo: directdebit.AccountBindingAdditionalInfo{
+			MaskedCard: card.MaskedCard,
+			BankCode:   card.BankCode,
+		},
+	}, nil
+}
+
+func (s *Server) debit(r *request) (any, error) {
+	var body directdebit.DebitRequest
+	if err := r.decode(&body); err != nil {
+		return nil, err
+	}
+
+	card, ok := s.cards[body.BankCardToken]
+	if !ok || card.TokenStatus != TokenStatusActive || card.PublicUserID != r.publicUserID {
+		return nil, r.fail(http.StatusNotFound, "11", "")
+	}
+
+	amount, err := strconv.ParseFloat(body.Amount.Value, 64)
+	if err != nil || amount <= 0 {
+		return nil, r.fail(http.StatusNotFound, "13", "")
+	}
+
+	if err := s.reserveReference(r, body.PartnerReferenceNo, body.MerchantID); err != nil {
+		return nil, err
+	}
+
+	debit := &Debit{
+		PartnerReferenceNo: body.PartnerReferenceNo,
+		ReferenceNo:        randomDigits(20),
+		ExternalID:         r.Header.Get("X-EXTERNAL-ID"),
+		AccountToken:       card.AccountToken,
+		PublicUserID:       card.PublicUserID,
+		Amount:             body.Amount,
+		Remarks:            body.AdditionalInfo.Remarks,
+		Status:             directdebit.TransactionStatusSuccess,
+	}
+	s.debits[debit.PartnerReferenceNo] = debit
+
+	if body.AdditionalInfo.OtpAllowed == "YES" {
+		debit.Status = directdebit.TransactionStatusInitiated
+
+		resp := debitResponse(r, debit)
+		resp.ResponseCode = directdebit.ResponseCode("202" + r.route.service + "00")
+		resp.ResponseMessage = "Request In Progress"
+		resp.AdditionalInfo.PaymentResult = ""
+
+		return accepted{resp}, nil
+	}
+
+	return debitResponse(r, debit), nil
+}
+
+func (s *Server) debitStatus(r *request) (any, error) {
+	query := r.URL.Query()
+	if query.Get("merchantId") != s.MerchantID {
+		return nil, r.fail(http.StatusNotFound, "08", "")
+	}
+
+	externalID := query.Get("XExternalId")
+	for _, debit := range s.debits {
+		if debit.ExternalID != "" && debit.ExternalID == externalID {
+			resp := debitResponse(r, debit)
+			resp.LatestTransactionStatus = debit.Status
+			resp.TransactionStatusDesc = transactionStatusDesc[debit.Status]
+
+			return resp, nil
+		}
+	}
+
+	return nil, r.fail(http.StatusNotFound, "01", "")
+}
+
+func debitResponse(r *request, debit *Debit) *directdebit.DebitResponse {
+	return &directdebit.DebitResponse{
+		ResponseCode:       r.success(),
+		ResponseMessage:    "Successful",
+		PartnerReferenceNo: debit.PartnerReferenceNo,
+		ReferenceNo:        debit.ReferenceNo,
+		Amount:             debit.Amount,
+		AdditionalInfo: directdebit.DebitAdditionalInfo{
+			PublicUserID:  debit.PublicUserID,
+			Remarks:       debit.Remarks,
+			PaymentResult: "success",
+		},
+	}
+}
+
+func (s *Server) unbind(r *request) (any, error) {
+	var body directdebit.AccountUnbindRequest
+	if err := r.decode(&body); err != nil {
+		return nil, err
+	}
+
+	card, ok := s.cards[body.AdditionalInfo.AccountToken]
+	if !ok || card.TokenStatus != TokenStatusActive || card.PublicUserID != body.AdditionalInfo.PublicUserID {
+		return nil, r.fail(http.StatusNotFound, "11", "")
+	}
+
+	if err := s.reserveReference(r, body.PartnerReferenceNo, body.MerchantID); err != nil {
+		return nil, err
+	}
+
+	card.TokenStatus = TokenStatusInactive
+
+	return &directdebit.AccountUnbindResponse{
+		ResponseCode:       r.success(),
+		ResponseMessage:    "Successful",
+		PartnerReferenceNo: body.PartnerReferenceNo,
+		ReferenceNo:        randomDigits(20),
+		UnlinkResult:       "success",
+	}, nil
+}
+
+// verifyOTP completes debits waiting for OTP, other OTP actions are not supported.
+func (s *Server) verifyOTP(r *request) (any, error) {
+	var body directdebit.VerifyOTPRequest
+	if err := r.decode(&body); err != nil {
+		return nil, err
+	}
+
+	if body.Action != directdebit.OTPActionPayment {
+		return nil, r.fail(http.StatusMethodNotAllowed, "01", "action")
+	}
+
+	var debit *Debit
+	for _, d := range s.debits {
+		if body.OriginalReferenceNo != "" && d.ReferenceNo == body.OriginalReferenceNo {
+			debit = d
+			break
+		}
+	}
+
+	if debit == nil || debit.PublicUserID != r.publicUserID ||
+		(body.OriginalPartnerReferenceNo != "" && body.OriginalPartnerReferenceNo != debit.PartnerReferenceNo) {
+		return nil, r.fail(http.StatusNotFound, "01", "")
+	}
+
+	if debit.Status != directdebit.TransactionStatusInitiated {
+		return nil, r.fail(http.StatusNotFound, "00", "")
+	}
+
+	if body.OTP != s.OTP {
+		return nil, r.fail(http.StatusNotFound, "15", "")
+	}
+
+	if err := s.reserveReference(r, body.PartnerReferenceNo, body.MerchantID); err != nil {
+		return nil, err
+	}
+
+	debit.Status = directdebit.TransactionStatusSuccess
+
+	return &directdebit.VerifyOTPResponse{
+		ResponseCode:        r.success(),
+		ResponseMessage:     "Successful",
+		PartnerReferenceNo:  body.PartnerReferenceNo,
+		OriginalReferenceNo: debit.ReferenceNo,
+		ReferenceNo:         randomDigits(20),
+		UserInfo:            directdebit.UserInfo{PublicUserID: debit.PublicUserID},
+		Amount:              debit.Amount,
+		AdditionalInfo:      directdebit.VerifyOTPResponseAdditionalInfo{PaymentResult: "success"},
+	}, nil
+}
+
+// cardList returns the active cards of a user, ordered by account token.
+func (s *Server) cardList(r *request) (any, error) {
+	var body directdebit.GetCardsRequest
+	if err := r.decode(&body); err != nil {
+		return nil, err
+	}
+
+	if body.MerchantID != s.MerchantID {
+		return nil, r.fail(http.StatusNotFound, "08", "")
+	}
+
+	if body.PublicUserID == "" {
+		return nil, r.fail(http.StatusBadRequest, "02", "publicUserId")
+	}
+
+	cards := []directdebit.Card{}
+	for _, card := range s.cards {
+		if card.PublicUserID != body.PublicUserID || card.TokenStatus != TokenStatusActive {
+			continue
+		}
+
+		cards = append(cards, directdebit.Card{
+			MaskedCard:   card.MaskedCard,
+			BankCode:     card.BankCode,
+			AccountToken: card.AccountToken,
+			TokenStatus:  card.TokenStatus,
+		})
+	}
+
+	sort.Slice(cards, func(i, j int) bool {
+		return cards[i].AccountToken < cards[j].AccountToken
+	})
+
+	return &directdebit.GetCardListResponse{
+		ResponseCode:       r.success(),
+		ResponseMessage:    "Successful",
+		PartnerReferenceNo: body.PartnerReferenceNo,
+		UserInfo:           directdebit.UserInfo{PublicUserID: body.PublicUserID},
+		Cards:              cards,
+	}, nil
+}
+
+// refund refunds successful debits, partially or fully. A debit whose amount has
+// been refunded entirely becomes "04" (refunded).
+func (s *Server) refund(r *request) (any, error) {
+	var body directdebit.RefundRequest
+	if err := r.decode(&body); err != nil {
+		return nil, err
+	}
+
+	debit, ok := s.debits[body.OriginalPartnerReferenceNo]
+	if !ok || (body.OriginalReferenceNo != "" && body.OriginalReferenceNo != debit.ReferenceNo) {
+		return nil, r.fail(http.StatusNotFound, "01", "")
+	}
+
+	if debit.Status != directdebit.TransactionStatusSuccess {
+		return nil, r.fail(http.StatusNotFound, "00", "")
+	}
+
+	remaining := cents(debit.Amount.Value)
+	for _, refund := range s.refunds {
+		if refund.OriginalPartnerReferenceNo == debit.PartnerReferenceNo {
+			remaining -= cents(refund.Amount.Value)
+		}
+	}
+
+	amount := cents(body.RefundAmount.Value)
+	if amount <= 0 || amount > remaining {
+		return nil, r.fail(http.StatusNotFound, "13", "")
+	}
+
+	if err := s.reserveReference(r, body.PartnerRefundNo, body.MerchantID); err != nil {
+		return nil, err
+	}
+
+	refund := &Refund{
+		PartnerRefundNo:            body.PartnerRefundNo,
+		RefundNo:                   randomDigits(20),
+		ExternalID:                 r.Header.Get("X-EXTERNAL-ID"),
+		OriginalPartnerReferenceNo: debit.PartnerReferenceNo,
+		Amount:                     body.RefundAmount,
+	}
+	s.refunds[refund.PartnerRefundNo] = refund
+
+	if amount == remaining {
+		debit.Status = directdebit.TransactionStatusRefunded
+	}
+
+	return refundResponse(r, refund, debit), nil
+}
+
+func (s *Server) refundStatus(r *request) (any, error) {
+	query := r.URL.Query()
+	if query.Get("merchantId") != s.MerchantID {
+		return nil, r.fail(http.StatusNotFound, "08", "")
+	}
+
+	externalID := query.Get("XExternalId")
+	for _, refund := range s.refunds {
+		if refund.ExternalID != "" && refund.ExternalID == externalID {
+			return refundResponse(r, refund, s.debits[refund.OriginalPartnerReferenceNo]), nil
+		}
+	}
+
+	return nil, r.fail(http.StatusNotFound, "01", "")
+}
+
+func refundResponse(r *request, refund *Refund, debit *Debit) *directdebit.RefundResponse {
+	return &directdebit.RefundResponse{
+		ResponseCode:               r.success(),
+		ResponseMessage:            "Successful",
+		OriginalPartnerReferenceNo: debit.PartnerReferenceNo,
+		OriginalReferenceNo:        debit.ReferenceNo,
+		PartnerRefundNo:            refund.PartnerRefundNo,
+		RefundNo:                   refund.RefundNo,
+		RefundAmount:               refund.Amount,
+		RefundTime:                 time.Now().Format(time.RFC3339),
+		AdditionalInfo: directdebit.RefundResponseAdditionalInfo{
+			PublicUserID: debit.PublicUserID,
+			RefundResult: "success",
+		},
+	}
+}
+
+// cancelDebit cancels debits that have not settled yet, see Server.SetDebitStatus.
+func (s *Server) cancelDebit(r *request) (any, error) {
+	var body directdebit.CancelDebitRequest
+	if err := r.decode(&body); err != nil {
+		return nil, err
+	}
+
+	if body.MerchantID != s.MerchantID {
+		return nil, r.fail(http.StatusNotFound, "08", "")
+	}
+
+	debit, ok := s.debits[body.OriginalPartnerReferenceNo]
+	if !ok || (body.OriginalReferenceNo != "" && body.OriginalReferenceNo != debit.ReferenceNo) {
+		return nil, r.fail(http.StatusNotFound, "01", "")
+	}
+
+	switch debit.Status {
+	case directdebit.TransactionStatusInitiated, directdebit.TransactionStatusPaying, directdebit.TransactionStatusPending:
+	default:
+		return nil, r.fail(http.StatusNotFound, "00", "")
+	}
+
+	debit.Status = directdebit.TransactionStatusCanceled
+
+	now := time.Now().Format(time.RFC3339)
+
+	return &directdebit.CancelDebitResponse{
+		ResponseCode:               r.success(),
+		ResponseMessage:            "Successful",
+		OriginalPartnerReferenceNo: debit.PartnerReferenceNo,
+		OriginalReferenceNo:        debit.ReferenceNo,
+		CancelTime:                 now,
+		TransactionDate:            now,
+		AdditionalInfo: directdebit.CancelDebitResponseAdditionalInfo{
+			PublicUserID:  debit.PublicUserID,
+			PaymentResult: "cancelled",
+		},
+	}, nil
+}
+
+// reserveReference checks the merchant and partnerReferenceNo of a request and
+// records the reference as used by the route.
+func (s *Server) reserveReference(r *request, partnerReferenceNo, merchantID string) error {
+	if partnerReferenceNo == "" {
+		return r.fail(http.StatusBadRequest, "02", "partnerReferenceNo")
+	}
+
+	if merchantID != s.MerchantID {
+		return r.fail(http.StatusNotFound, "08", "")
+	}
+
+	key := r.route.operation + ":" + partnerReferenceNo
+	if s.references[key] {
+		return r.fail(http.StatusConflict, "01", "")
+	}
+	s.references[key] = true
+
+	return nil
+}
+
+// cents parses an amount value such as "10000.00", invalid values are 0.
+func cents(value string) int64 {
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+
+	return int64(math.Round(amount * 100))
+}
+
+func bearerToken(header string) string {
+	return strings.TrimPrefix(header, "Bearer ")
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic("directdebittest: " + err.Error())
+	}
+
+	return hex.EncodeToString(b)
+}
+
+func randomDigits(n int) string {
+	digits := make([]byte, n)
+	for i := range digits {
+		d, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			panic("directdebittest: " + err.Error())
+		}
+		digits[i] = byte('0' + d.Int64())
+	}
+
+	return string(digits)
+}
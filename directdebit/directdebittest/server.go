@@ -0,0 +1,330 @@
+// Package directdebittest provides an in-process fake of the Ayoconnect direct debit
+// API for integration tests.
+//
+// The fake checks the X-TIMESTAMP and X-SIGNATURE headers the way Ayoconnect does,
+// keeps the cards bound and the debits and refunds made through it, and can be
+// scripted to answer with any SNAP response code or to respond slowly:
+//
+//	srv := directdebittest.NewServer()
+//	defer srv.Close()
+//
+//	client, _ := directdebit.New(srv.Config())
+//	srv.Inject(directdebittest.Fault{
+//		Operation:    directdebit.OperationDebit,
+//		ResponseCode: "4035414",
+//		Times:        1,
+//	})
+package directdebittest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit"
+)
+
+// Default credentials of a Server.
+const (
+	DefaultClientID     = "test-client-id"
+	DefaultClientSecret = "test-client-secret"
+	DefaultMerchantID   = "test-merchant-id"
+	DefaultOTP          = "123456"
+)
+
+// Card is a card bound through the Server.
+type Card struct {
+	AccountToken string
+	PublicUserID string
+	MobileNumber string
+	BankCode     string
+	MaskedCard   string
+	// TokenStatus is "ACTIVE" until the card is unbound, then "INACTIVE".
+	TokenStatus string
+}
+
+// Debit is a debit made through the Server.
+type Debit struct {
+	PartnerReferenceNo string
+	ReferenceNo        string
+	// ExternalID is the X-EXTERNAL-ID of the debit request, DebitStatus looks
+	// debits up by it.
+	ExternalID   string
+	AccountToken string
+	PublicUserID string
+	Amount       directdebit.Amount
+	Remarks      string
+	// Status is one of the directdebit.TransactionStatus constants. Debits that
+	// allow OTP are "01" (initiated) until the OTP is verified.
+	Status string
+}
+
+// Refund is a refund made through the Server.
+type Refund struct {
+	PartnerRefundNo string
+	RefundNo        string
+	// ExternalID is the X-EXTERNAL-ID of the refund request, RefundStatus looks
+	// refunds up by it.
+	ExternalID                 string
+	OriginalPartnerReferenceNo string
+	Amount                     directdebit.Amount
+}
+
+// Fault changes how the Server answers requests.
+type Fault struct {
+	// Operation is the directdebit.Operation constant of the requests the fault
+	// applies to, an empty Operation matches every request.
+	Operation string
+	// ResponseCode is returned instead of the normal response when it is not
+	// empty. The HTTP status is taken from the code.
+	ResponseCode directdebit.ResponseCode
+	// ResponseMessage defaults to the description of ResponseCode.
+	ResponseMessage string
+	// Delay is waited before responding. When ResponseCode is empty the request is
+	// then handled normally, so a client that gives up early leaves the state
+	// changed, like a request that timed out at Ayoconnect.
+	Delay time.Duration
+	// Times is the number of requests the fault applies to, zero applies it to
+	// every request.
+	Times int
+}
+
+// Server is a fake Ayoconnect API listening on a local address.
+type Server struct {
+	// URL is the base URL of the server, of the form http://ipaddr:port.
+	URL          string
+	ClientID     string
+	ClientSecret string
+	MerchantID   string
+	// PrivateKey is the PEM encoded PKCS#8 key clients sign access token requests
+	// with, and PublicKey the PEM encoded PKIX key the server verifies them with.
+	PrivateKey string
+	PublicKey  string
+	// MaxClockSkew is the largest difference allowed between X-TIMESTAMP and the
+	// local clock. Defaults to directdebit.DefaultMaxClockSkew.
+	MaxClockSkew time.Duration
+	// OTP is the code VerifyOTP accepts.
+	OTP string
+
+	server *httptest.Server
+
+	mu             sync.Mutex
+	businessTokens map[string]bool
+	customerTokens map[string]string
+	authCodes      map[string]*authCode
+	users          map[string]string
+	cards          map[string]*Card
+	debits         map[string]*Debit
+	refunds        map[string]*Refund
+	references     map[string]bool
+	faults         []*Fault
+}
+
+type authCode struct {
+	mobileNumber string
+	bankCode     string
+	// publicUserID is set once the code has been used to bind a card.
+	publicUserID string
+}
+
+// NewServer starts a Server with the default credentials and a freshly generated
+// RSA key. The caller should call Close when finished.
+func NewServer() *Server {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic("directdebittest: failed to generate key: " + err.Error())
+	}
+
+	privateKey, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		panic("directdebittest: failed to encode private key: " + err.Error())
+	}
+
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		panic("directdebittest: failed to encode public key: " + err.Error())
+	}
+
+	s := &Server{
+		ClientID:       DefaultClientID,
+		ClientSecret:   DefaultClientSecret,
+		MerchantID:     DefaultMerchantID,
+		OTP:            DefaultOTP,
+		PrivateKey:     string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateKey})),
+		PublicKey:      string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey})),
+		businessTokens: map[string]bool{},
+		customerTokens: map[string]string{},
+		authCodes:      map[string]*authCode{},
+		users:          map[string]string{},
+		cards:          map[string]*Card{},
+		debits:         map[string]*Debit{},
+		refunds:        map[string]*Refund{},
+		references:     map[string]bool{},
+	}
+
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.server.URL
+
+	return s
+}
+
+// Close shuts down the server and blocks until all outstanding requests on it have
+// completed.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Config returns a client configuration pointing at the server, with its
+// credentials and a discarding logger.
+func (s *Server) Config() *directdebit.Config {
+	return &directdebit.Config{
+		ClientID:        s.ClientID,
+		ClientSecret:    s.ClientSecret,
+		MerchantID:      s.MerchantID,
+		RsaPrivateKey:   s.PrivateKey,
+		EndpointBaseURL: s.URL,
+		HTTPClient:      s.server.Client(),
+		Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+}
+
+// Inject adds a fault. Faults are matched in the order they were added, the first
+// one matching a request is applied.
+func (s *Server) Inject(fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, &fault)
+}
+
+// ClearFaults removes every injected fault.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = nil
+}
+
+// Cards returns a copy of every card bound through the server, unbound cards
+// included.
+func (s *Server) Cards() []Card {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cards := make([]Card, 0, len(s.cards))
+	for _, card := range s.cards {
+		cards = append(cards, *card)
+	}
+
+	return cards
+}
+
+// Card returns a copy of the card bound with accountToken.
+func (s *Server) Card(accountToken string) (Card, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	card, ok := s.cards[accountToken]
+	if !ok {
+		return Card{}, false
+	}
+
+	return *card, true
+}
+
+// Debits returns a copy of every debit made through the server.
+func (s *Server) Debits() []Debit {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	debits := make([]Debit, 0, len(s.debits))
+	for _, debit := range s.debits {
+		debits = append(debits, *debit)
+	}
+
+	return debits
+}
+
+// Debit returns a copy of the debit made with partnerReferenceNo.
+func (s *Server) Debit(partnerReferenceNo string) (Debit, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	debit, ok := s.debits[partnerReferenceNo]
+	if !ok {
+		return Debit{}, false
+	}
+
+	return *debit, true
+}
+
+// SetDebitStatus changes the status DebitStatus reports for the debit made with
+// partnerReferenceNo, status is one of the directdebit.TransactionStatus
+// constants. It reports whether the debit exists.
+func (s *Server) SetDebitStatus(partnerReferenceNo string, status string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	debit, ok := s.debits[partnerReferenceNo]
+	if ok {
+		debit.Status = status
+	}
+
+	return ok
+}
+
+// Refunds returns a copy of every refund made through the server.
+func (s *Server) Refunds() []Refund {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	refunds := make([]Refund, 0, len(s.refunds))
+	for _, refund := range s.refunds {
+		refunds = append(refunds, *refund)
+	}
+
+	return refunds
+}
+
+// Refund returns a copy of the refund made with partnerRefundNo.
+func (s *Server) Refund(partnerRefundNo string) (Refund, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	refund, ok := s.refunds[partnerRefundNo]
+	if !ok {
+		return Refund{}, false
+	}
+
+	return *refund, true
+}
+
+// fault returns the first fault matching operation and counts its use.
+func (s *Server) fault(operation string) (Fault, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, f := range s.faults {
+		if f.Operation != "" && f.Operation != operation {
+			continue
+		}
+
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i:i], s.faults[i+1:]...)
+			}
+		}
+
+		return *f, true
+	}
+
+	return Fault{}, false
+}
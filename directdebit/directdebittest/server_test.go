@@ -0,0 +1,473 @@
+package directdebittest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit"
+	"github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit/directdebittest"
+)
+
+func newClient(t *testing.T, srv *directdebittest.Server) *directdebit.Client {
+	t.Helper()
+
+	client, err := directdebit.New(srv.Config())
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	return client
+}
+
+// bindCard binds a card for mobileNumber and returns the binding response and a B2B2C
+// access token of its owner.
+func bindCard(t *testing.T, client *directdebit.Client, mobileNumber string) (*directdebit.AccountBindingResponse, string) {
+	t.Helper()
+	ctx := context.Background()
+
+	authCode, err := client.GetAuthCode(ctx, &directdebit.GetAuthCodeRequest{
+		State:        "state",
+		SeamlessData: directdebit.SeamlessData{MobileNumber: mobileNumber, BankCode: "CENAIDJA"},
+	}, "", "externalID")
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	binding, err := client.AccountBinding(ctx, &directdebit.AccountBindingRequest{
+		PartnerReferenceNo: "binding-" + mobileNumber,
+		AuthCode:           authCode.AuthCode,
+	}, "", "externalID")
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	token, err := client.GetCustomerAccessToken(ctx, authCode.AuthCode, "")
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	return binding, token.AccessToken
+}
+
+func TestServerFlow(t *testing.T) {
+	srv := directdebittest.NewServer()
+	defer srv.Close()
+
+	client := newClient(t, srv)
+	ctx := context.Background()
+
+	binding, customerToken := bindCard(t, client, "081234567890")
+	if binding.ResponseCode != "2000700" || binding.AccountToken == "" || binding.UserInfo.PublicUserID == "" {
+		t.Fatalf("Unexpected binding response %+v", binding)
+	}
+
+	card, ok := srv.Card(binding.AccountToken)
+	if !ok || card.MobileNumber != "081234567890" || card.TokenStatus != directdebittest.TokenStatusActive {
+		t.Fatalf("Expected the card to be tracked, got %+v", card)
+	}
+
+	debit, err := client.Debit(ctx, &directdebit.DebitRequest{
+		PartnerReferenceNo: "debit-1",
+		BankCardToken:      binding.AccountToken,
+		Amount:             directdebit.Amount{Value: "10000.00", Currency: "IDR"},
+		AdditionalInfo:     directdebit.DebitAdditionalInfo{PublicUserID: binding.UserInfo.PublicUserID},
+	}, "", customerToken, "debitExternalID")
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	if debit.ResponseCode != "2005400" || debit.ReferenceNo == "" {
+		t.Fatalf("Unexpected debit response %+v", debit)
+	}
+
+	srv.SetDebitStatus("debit-1", directdebit.TransactionStatusPending)
+
+	status, err := client.DebitStatus(ctx, "", "debitExternalID", "externalID")
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	if status.LatestTransactionStatus != directdebit.TransactionStatusPending || status.PartnerReferenceNo != "debit-1" {
+		t.Errorf("Unexpected status response %+v", status)
+	}
+
+	_, err = client.Unbind(ctx, &directdebit.AccountUnbindRequest{
+		PartnerReferenceNo: "unbind-1",
+		AdditionalInfo: directdebit.AccountUnbindRequestAdditionalInfo{
+			PublicUserID: binding.UserInfo.PublicUserID,
+			AccountToken: binding.AccountToken,
+		},
+	}, "", customerToken, "externalID")
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	if card, _ := srv.Card(binding.AccountToken); card.TokenStatus != directdebittest.TokenStatusInactive {
+		t.Errorf("Expected the card to be unbound, got %s", card.TokenStatus)
+	}
+
+	_, err = client.Debit(ctx, &directdebit.DebitRequest{
+		PartnerReferenceNo: "debit-2",
+		BankCardToken:      binding.AccountToken,
+		Amount:             directdebit.Amount{Value: "10000.00", Currency: "IDR"},
+	}, "", customerToken, "externalID2")
+
+	var respErr *directdebit.ResponseError
+	if !errors.As(err, &respErr) || respErr.ResponseCode != "4045411" {
+		t.Errorf("Expected debiting an unbound card to fail with 4045411, got %v", err)
+	}
+
+	if debits := srv.Debits(); len(debits) != 1 {
+		t.Errorf("Expected 1 debit, got %d", len(debits))
+	}
+}
+
+func TestServerRejectsInvalidSignature(t *testing.T) {
+	srv := directdebittest.NewServer()
+	defer srv.Close()
+
+	cfg := srv.Config()
+	cfg.ClientSecret = "wrong"
+	client, err := directdebit.New(cfg)
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	_, err = client.DebitStatus(context.Background(), "", "debitExternalID", "externalID")
+
+	var respErr *directdebit.ResponseError
+	if !errors.As(err, &respErr) || respErr.ResponseCode != "4015500" {
+		t.Fatalf("Expected 4015500, got %v", err)
+	}
+
+	if !errors.Is(err, directdebit.ErrAuthFailure) {
+		t.Errorf("Expected an authentication failure, got %v", err)
+	}
+}
+
+func TestServerRejectsDuplicateReference(t *testing.T) {
+	srv := directdebittest.NewServer()
+	defer srv.Close()
+
+	client := newClient(t, srv)
+	binding, customerToken := bindCard(t, client, "081234567890")
+
+	req := directdebit.DebitRequest{
+		PartnerReferenceNo: "debit-1",
+		BankCardToken:      binding.AccountToken,
+		Amount:             directdebit.Amount{Value: "10000.00", Currency: "IDR"},
+	}
+
+	first := req
+	if _, err := client.Debit(context.Background(), &first, "", customerToken, "externalID1"); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	second := req
+	_, err := client.Debit(context.Background(), &second, "", customerToken, "externalID2")
+	if !errors.Is(err, directdebit.ErrDuplicate) {
+		t.Errorf("Expected a duplicate error, got %v", err)
+	}
+}
+
+func TestServerInject(t *testing.T) {
+	srv := directdebittest.NewServer()
+	defer srv.Close()
+
+	client := newClient(t, srv)
+	binding, customerToken := bindCard(t, client, "081234567890")
+
+	srv.Inject(directdebittest.Fault{
+		Operation:    directdebit.OperationDebit,
+		ResponseCode: "4035414",
+		Times:        1,
+	})
+
+	debit := func(ref string) error {
+		_, err := client.Debit(context.Background(), &directdebit.DebitRequest{
+			PartnerReferenceNo: ref,
+			BankCardToken:      binding.AccountToken,
+			Amount:             directdebit.Amount{Value: "10000.00", Currency: "IDR"},
+		}, "", customerToken, ref)
+
+		return err
+	}
+
+	if err := debit("debit-1"); !errors.Is(err, directdebit.ErrInsufficientFunds) {
+		t.Errorf("Expected insufficient funds, got %v", err)
+	}
+
+	if err := debit("debit-2"); err != nil {
+		t.Errorf("Expected the fault to apply once, got %v", err)
+	}
+
+	srv.Inject(directdebittest.Fault{Operation: directdebit.OperationDebit, Delay: 200 * time.Millisecond})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.Debit(ctx, &directdebit.DebitRequest{
+		PartnerReferenceNo: "debit-3",
+		BankCardToken:      binding.AccountToken,
+		Amount:             directdebit.Amount{Value: "10000.00", Currency: "IDR"},
+	}, "", customerToken, "debit-3")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the delayed request to time out, got %v", err)
+	}
+
+	srv.ClearFaults()
+	srv.Close()
+
+	if _, ok := srv.Debit("debit-3"); !ok {
+		t.Errorf("Expected the delayed debit to be recorded after the client gave up")
+	}
+}
+
+func TestServerUnknownEndpoint(t *testing.T) {
+	srv := directdebittest.NewServer()
+	defer srv.Close()
+
+	client := newClient(t, srv)
+
+	_, err := client.Execute(context.Background(), http.MethodPost, "/api/v1.0/unknown", directdebit.RequestHeader{}, nil)
+
+	var respErr *directdebit.ResponseError
+	if !errors.As(err, &respErr) || respErr.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected a 405 response, got %v", err)
+	}
+}
+
+func TestServerVerifyOTP(t *testing.T) {
+	srv := directdebittest.NewServer()
+	defer srv.Close()
+
+	client := newClient(t, srv)
+	ctx := context.Background()
+	binding, customerToken := bindCard(t, client, "081234567890")
+
+	debit, err := client.Debit(ctx, &directdebit.DebitRequest{
+		PartnerReferenceNo: "debit-1",
+		BankCardToken:      binding.AccountToken,
+		Amount:             directdebit.Amount{Value: "10000.00", Currency: "IDR"},
+		AdditionalInfo: directdebit.DebitAdditionalInfo{
+			PublicUserID: binding.UserInfo.PublicUserID,
+			OtpAllowed:   "YES",
+		},
+	}, "", customerToken, "debitExternalID")
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	if debit.ResponseCode != "2025400" {
+		t.Fatalf("Expected the debit to wait for OTP, got %+v", debit)
+	}
+
+	if d, _ := srv.Debit("debit-1"); d.Status != directdebit.TransactionStatusInitiated {
+		t.Errorf("Expected the debit to be initiated, got %s", d.Status)
+	}
+
+	verify := func(ref, otp string) (*directdebit.VerifyOTPResponse, error) {
+		return client.VerifyOTP(ctx, &directdebit.VerifyOTPRequest{
+			PartnerReferenceNo:         ref,
+			OriginalPartnerReferenceNo: "debit-1",
+			OriginalReferenceNo:        debit.ReferenceNo,
+			Action:                     directdebit.OTPActionPayment,
+			OTP:                        otp,
+			AdditionalInfo:             directdebit.VerifyOTPRequestAdditionalInfo{PublicUserID: binding.UserInfo.PublicUserID},
+		}, "", customerToken, ref)
+	}
+
+	_, err = verify("otp-1", "000000")
+	var respErr *directdebit.ResponseError
+	if !errors.As(err, &respErr) || respErr.ResponseCode != "4040415" {
+		t.Fatalf("Expected a wrong OTP to fail with 4040415, got %v", err)
+	}
+
+	resp, err := verify("otp-2", directdebittest.DefaultOTP)
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	if resp.ResponseCode != "2000400" || resp.OriginalReferenceNo != debit.ReferenceNo {
+		t.Errorf("Unexpected verify OTP response %+v", resp)
+	}
+
+	if d, _ := srv.Debit("debit-1"); d.Status != directdebit.TransactionStatusSuccess {
+		t.Errorf("Expected the debit to succeed, got %s", d.Status)
+	}
+
+	_, err = verify("otp-3", directdebittest.DefaultOTP)
+	if !errors.As(err, &respErr) || respErr.ResponseCode != "4040400" {
+		t.Errorf("Expected verifying a completed debit to fail with 4040400, got %v", err)
+	}
+}
+
+func TestServerGetCardList(t *testing.T) {
+	srv := directdebittest.NewServer()
+	defer srv.Close()
+
+	client := newClient(t, srv)
+	ctx := context.Background()
+	first, _ := bindCard(t, client, "081234567890")
+	bindCard(t, client, "089876543210")
+
+	authCode, err := client.GetAuthCode(ctx, &directdebit.GetAuthCodeRequest{
+		State:        "state",
+		SeamlessData: directdebit.SeamlessData{MobileNumber: "081234567890", BankCode: "CENAIDJA"},
+	}, "", "externalID")
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	second, err := client.AccountBinding(ctx, &directdebit.AccountBindingRequest{
+		PartnerReferenceNo: "binding-2",
+		AuthCode:           authCode.AuthCode,
+	}, "", "externalID")
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	resp, err := client.GetCardList(ctx, &directdebit.GetCardsRequest{
+		PartnerReferenceNo: "cards-1",
+		PublicUserID:       first.UserInfo.PublicUserID,
+	}, "", "externalID")
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	if resp.ResponseCode != "2000100" || len(resp.Cards) != 2 {
+		t.Fatalf("Expected the 2 cards of the user, got %+v", resp)
+	}
+
+	for _, card := range resp.Cards {
+		if card.AccountToken != first.AccountToken && card.AccountToken != second.AccountToken {
+			t.Errorf("Unexpected card %+v", card)
+		}
+	}
+}
+
+func TestServerRefund(t *testing.T) {
+	srv := directdebittest.NewServer()
+	defer srv.Close()
+
+	client := newClient(t, srv)
+	ctx := context.Background()
+	binding, customerToken := bindCard(t, client, "081234567890")
+
+	debit, err := client.Debit(ctx, &directdebit.DebitRequest{
+		PartnerReferenceNo: "debit-1",
+		BankCardToken:      binding.AccountToken,
+		Amount:             directdebit.Amount{Value: "10000.00", Currency: "IDR"},
+		AdditionalInfo:     directdebit.DebitAdditionalInfo{PublicUserID: binding.UserInfo.PublicUserID},
+	}, "", customerToken, "debitExternalID")
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	refund := func(ref, value string) (*directdebit.RefundResponse, error) {
+		return client.Refund(ctx, &directdebit.RefundRequest{
+			PartnerRefundNo:            ref,
+			OriginalPartnerReferenceNo: "debit-1",
+			OriginalReferenceNo:        debit.ReferenceNo,
+			RefundAmount:               directdebit.Amount{Value: value, Currency: "IDR"},
+		}, "", customerToken, ref+"-externalID")
+	}
+
+	resp, err := refund("refund-1", "4000.00")
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	if resp.ResponseCode != "2005800" || resp.RefundNo == "" {
+		t.Errorf("Unexpected refund response %+v", resp)
+	}
+
+	if d, _ := srv.Debit("debit-1"); d.Status != directdebit.TransactionStatusSuccess {
+		t.Errorf("Expected a partially refunded debit to stay successful, got %s", d.Status)
+	}
+
+	var respErr *directdebit.ResponseError
+	if _, err := refund("refund-2", "7000.00"); !errors.As(err, &respErr) || respErr.ResponseCode != "4045813" {
+		t.Errorf("Expected refunding more than the debit to fail with 4045813, got %v", err)
+	}
+
+	if _, err := refund("refund-3", "6000.00"); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	if d, _ := srv.Debit("debit-1"); d.Status != directdebit.TransactionStatusRefunded {
+		t.Errorf("Expected the debit to be refunded, got %s", d.Status)
+	}
+
+	status, err := client.RefundStatus(ctx, "", "refund-3-externalID", "externalID")
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	if status.PartnerRefundNo != "refund-3" || status.RefundAmount.Value != "6000.00" {
+		t.Errorf("Unexpected refund status %+v", status)
+	}
+
+	if _, err := client.RefundStatus(ctx, "", "unknown", "externalID"); !errors.As(err, &respErr) || respErr.ResponseCode != "4045801" {
+		t.Errorf("Expected an unknown refund to fail with 4045801, got %v", err)
+	}
+
+	if refunds := srv.Refunds(); len(refunds) != 2 {
+		t.Errorf("Expected 2 refunds, got %d", len(refunds))
+	}
+}
+
+func TestServerCancelDebit(t *testing.T) {
+	srv := directdebittest.NewServer()
+	defer srv.Close()
+
+	client := newClient(t, srv)
+	ctx := context.Background()
+	binding, customerToken := bindCard(t, client, "081234567890")
+
+	for _, ref := range []string{"debit-1", "debit-2"} {
+		_, err := client.Debit(ctx, &directdebit.DebitRequest{
+			PartnerReferenceNo: ref,
+			BankCardToken:      binding.AccountToken,
+			Amount:             directdebit.Amount{Value: "10000.00", Currency: "IDR"},
+			AdditionalInfo:     directdebit.DebitAdditionalInfo{PublicUserID: binding.UserInfo.PublicUserID},
+		}, "", customerToken, ref)
+		if err != nil {
+			t.Fatalf("Did not expect an error, but got: %v", err)
+		}
+	}
+
+	srv.SetDebitStatus("debit-1", directdebit.TransactionStatusPending)
+
+	cancel := func(ref string) (*directdebit.CancelDebitResponse, error) {
+		return client.CancelDebit(ctx, &directdebit.CancelDebitRequest{
+			OriginalPartnerReferenceNo: ref,
+			Amount:                     directdebit.Amount{Value: "10000.00", Currency: "IDR"},
+		}, "", customerToken, "cancel-"+ref)
+	}
+
+	resp, err := cancel("debit-1")
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	if resp.ResponseCode != "2005700" {
+		t.Errorf("Unexpected cancel response %+v", resp)
+	}
+
+	if d, _ := srv.Debit("debit-1"); d.Status != directdebit.TransactionStatusCanceled {
+		t.Errorf("Expected the debit to be canceled, got %s", d.Status)
+	}
+
+	var respErr *directdebit.ResponseError
+	if _, err := cancel("debit-2"); !errors.As(err, &respErr) || !directdebit.IsDebitNotCancellableError(respErr.ResponseCode) {
+		t.Errorf("Expected a settled debit not to be cancellable, got %v", err)
+	}
+
+	if _, err := cancel("unknown"); !errors.As(err, &respErr) || !directdebit.IsDebitNotFoundError(respErr.ResponseCode) {
+		t.Errorf("Expected an unknown debit not to be found, got %v", err)
+	}
+}
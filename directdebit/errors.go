@@ -0,0 +1,161 @@
+package directdebit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// Error categories a *ResponseError matches with errors.Is, derived from its SNAP
+// response code or, when the response has none, from its HTTP status. Transport
+// errors caused by a timeout, such as http.Client.Timeout or the deadline of the
+// request context, match ErrTimeout too.
+var (
+	ErrAuthFailure       = errors.New("authentication failed")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrCardExpired       = errors.New("card expired")
+	ErrValidation        = errors.New("invalid request")
+	ErrTimeout           = errors.New("request timed out")
+	ErrDuplicate         = errors.New("duplicate request")
+	ErrServerError       = errors.New("server error")
+)
+
+// responseCase describes a SNAP case code. The category applies when the case code
+// is returned with its HTTP status, the case codes of each HTTP status are
+// independent.
+type responseCase struct {
+	description string
+	category    error
+}
+
+// responseCases is the SNAP response code table, keyed by HTTP status followed by
+// case code, without the service code in between.
+var responseCases = map[string]responseCase{
+	"20000": {"Successful", nil},
+	"20200": {"Request In Progress", nil},
+
+	"40000": {"Bad Request", ErrValidation},
+	"40001": {"Invalid Field Format", ErrValidation},
+	"40002": {"Invalid Mandatory Field", ErrValidation},
+
+	"40100": {"Unauthorized", ErrAuthFailure},
+	"40101": {"Invalid Token (B2B)", ErrAuthFailure},
+	"40102": {"Invalid Customer Token", ErrAuthFailure},
+	"40103": {"Token Not Found (B2B)", ErrAuthFailure},
+	"40104": {"Customer Token Not Found", ErrAuthFailure},
+
+	"40300": {"Transaction Expired", nil},
+	"40301": {"Feature Not Allowed", nil},
+	"40302": {"Exceeds Transaction Amount Limit", nil},
+	"40303": {"Suspected Fraud", nil},
+	"40304": {"Activity Count Limit Exceeded", nil},
+	"40305": {"Do Not Honor", nil},
+	"40306": {"Feature Not Allowed At This Time", nil},
+	"40307": {"Card Blocked", nil},
+	"40308": {"Card Expired", ErrCardExpired},
+	"40309": {"Dormant Account", nil},
+	"40310": {"Need To Set Token Limit", nil},
+	"40311": {"OTP Blocked", nil},
+	"40312": {"OTP Lifetime Expired", nil},
+	"40313": {"OTP Sent To Cardholder", nil},
+	"40314": {"Insufficient Funds", ErrInsufficientFunds},
+	"40315": {"Transaction Not Permitted", nil},
+	"40316": {"Suspend Transaction", nil},
+	"40317": {"Token Limit Exceeded", nil},
+	"40318": {"Inactive Card/Account/Customer", nil},
+	"40319": {"Merchant Blacklisted", nil},
+	"40320": {"Merchant Limit Exceed", nil},
+	"40321": {"Set Limit Not Allowed", nil},
+	"40322": {"Token Limit Invalid", nil},
+	"40323": {"Account Limit Exceed", nil},
+
+	"40400": {"Invalid Transaction Status", nil},
+	"40401": {"Transaction Not Found", nil},
+	"40402": {"Invalid Routing", nil},
+	"40403": {"Bank Not Supported By Switch", nil},
+	"40404": {"Transaction Cancelled", nil},
+	"40405": {"Merchant Is Not Registered For Card Registration Services", nil},
+	"40406": {"Need To Request OTP", nil},
+	"40407": {"Journey Not Found", nil},
+	"40408": {"Invalid Merchant", nil},
+	"40409": {"No Issuer", nil},
+	"40410": {"Invalid API Transition", nil},
+	"40411": {"Invalid Card/Account/Customer", nil},
+	"40412": {"Invalid Bill/Virtual Account", nil},
+	"40413": {"Invalid Amount", ErrValidation},
+	"40414": {"Paid Bill", nil},
+	"40415": {"Invalid OTP", nil},
+	"40416": {"Partner Not Found", nil},
+	"40417": {"Invalid Terminal", nil},
+	"40418": {"Inconsistent Request", ErrValidation},
+	"40419": {"Invalid Bill/Virtual Account", nil},
+
+	"40500": {"Requested Function Is Not Supported", nil},
+	"40501": {"Requested Operation Is Not Allowed", nil},
+
+	"40900": {"Conflict", ErrDuplicate},
+	"40901": {"Duplicate partnerReferenceNo", ErrDuplicate},
+
+	"42900": {"Too Many Requests", nil},
+
+	"50000": {"General Error", ErrServerError},
+	"50001": {"Internal Server Error", ErrServerError},
+	"50002": {"External Server Error", ErrServerError},
+
+	"50400": {"Timeout", ErrTimeout},
+}
+
+// Is reports whether the error belongs to the category target, one of ErrAuthFailure,
+// ErrInsufficientFunds, ErrCardExpired, ErrValidation, ErrTimeout, ErrDuplicate or
+// ErrServerError.
+func (e *ResponseError) Is(target error) bool {
+	category := e.Category()
+
+	return category != nil && category == target
+}
+
+// Category returns the error category of the response code, or nil when the code
+// does not fall in any category.
+func (e *ResponseError) Category() error {
+	if IsDebitCardDisabledError(e.ResponseCode) {
+		return ErrCardExpired
+	}
+
+	if category := e.ResponseCode.responseCase().category; category != nil {
+		return category
+	}
+
+	status := e.StatusCode
+	if code := e.ResponseCode.HTTPCode(); code != 0 {
+		// the response code is more specific than the status of the HTTP response
+		status = code
+	}
+
+	switch {
+	case status == http.StatusBadRequest:
+		return ErrValidation
+	case status == http.StatusUnauthorized:
+		return ErrAuthFailure
+	case status == http.StatusConflict:
+		return ErrDuplicate
+	case status == http.StatusGatewayTimeout || status == http.StatusRequestTimeout:
+		return ErrTimeout
+	case status >= 500 && status <= 599:
+		return ErrServerError
+	}
+
+	return nil
+}
+
+// wrapTimeout makes a transport error caused by a timeout match ErrTimeout. The
+// original error is kept in the chain.
+func wrapTimeout(err error) error {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+
+	return err
+}
@@ -0,0 +1,104 @@
+package directdebit_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit"
+)
+
+func TestResponseErrorCategory(t *testing.T) {
+	tests := []struct {
+		name         string
+		responseCode directdebit.ResponseCode
+		statusCode   int
+		want         error
+	}{
+		{"invalid field format", "4005401", 400, directdebit.ErrValidation},
+		{"unknown bad request case", "4005499", 400, directdebit.ErrValidation},
+		{"invalid b2b token", "4017301", 401, directdebit.ErrAuthFailure},
+		{"insufficient funds", "4035414", 403, directdebit.ErrInsufficientFunds},
+		{"card expired", "4035408", 403, directdebit.ErrCardExpired},
+		{"ayoconnect card expired", "4033318", 403, directdebit.ErrCardExpired},
+		{"invalid amount", "4045413", 404, directdebit.ErrValidation},
+		{"duplicate partner reference", "4095401", 409, directdebit.ErrDuplicate},
+		{"timeout", "5045400", 504, directdebit.ErrTimeout},
+		{"general error", "5005400", 500, directdebit.ErrServerError},
+		{"transaction not found", "4045501", 404, nil},
+		{"no response code", "", 503, directdebit.ErrServerError},
+		{"short response code", "40", 401, directdebit.ErrAuthFailure},
+		{"response code overrides status", "4015400", 200, directdebit.ErrAuthFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error = &directdebit.ResponseError{ResponseCode: tt.responseCode, StatusCode: tt.statusCode}
+			err = fmt.Errorf("debit: %w", err)
+
+			var respErr *directdebit.ResponseError
+			if !errors.As(err, &respErr) {
+				t.Fatalf("Expected errors.As to find *ResponseError")
+			}
+
+			if got := respErr.Category(); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Expected errors.Is(err, %v) to be true", tt.want)
+			}
+
+			if errors.Is(err, directdebit.ErrDuplicate) && tt.want != directdebit.ErrDuplicate {
+				t.Errorf("Expected errors.Is(err, %v) to be false", directdebit.ErrDuplicate)
+			}
+		})
+	}
+}
+
+func TestClientSideErrorShortResponseCode(t *testing.T) {
+	for _, code := range []directdebit.ResponseCode{"", "4", "40"} {
+		if directdebit.IsClientSideError(code) {
+			t.Errorf("Expected %q not to be a client side error", code)
+		}
+	}
+}
+
+func TestTransportTimeoutIsErrTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	newClient := func(timeout time.Duration) *directdebit.Client {
+		return &directdebit.Client{Config: &directdebit.Config{
+			EndpointBaseURL: server.URL,
+			HTTPClient:      &http.Client{Timeout: timeout},
+		}}
+	}
+
+	_, err := newClient(10*time.Millisecond).Execute(context.Background(), http.MethodGet, "/", directdebit.RequestHeader{}, nil)
+	if !errors.Is(err, directdebit.ErrTimeout) {
+		t.Errorf("Expected a client timeout to be ErrTimeout, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = newClient(0).Execute(ctx, http.MethodGet, "/", directdebit.RequestHeader{}, nil)
+	if !errors.Is(err, directdebit.ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a context deadline to be ErrTimeout and context.DeadlineExceeded, got %v", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+
+	_, err = newClient(0).Execute(ctx, http.MethodGet, "/", directdebit.RequestHeader{}, nil)
+	if errors.Is(err, directdebit.ErrTimeout) {
+		t.Errorf("Expected a canceled request not to be ErrTimeout, got %v", err)
+	}
+}
@@ -4,15 +4,23 @@ import "context"
 
 // TODO: pass context into all function.
 //
-//go:generate mockgen -destination=./mock/client.go -package=mock bitbucket.org/mid-kelola-indonesia/corepayment-service/pkg/ayoconnect ClientInterface
+//go:generate mockgen -destination=./mock/client.go -package=mock github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit ClientInterface
 type ClientInterface interface {
 	GetBusinessAccessToken(ctx context.Context) (*GetAccessTokenResponse, error)
 	AccountBinding(ctx context.Context, req *AccountBindingRequest, b2bToken, externalID string) (*AccountBindingResponse, error)
 	GetAuthCode(ctx context.Context, req *GetAuthCodeRequest, b2bToken, externalID string) (*GetAuthCodeResponse, error)
 	GetCustomerAccessToken(ctx context.Context, authCode, accessTokenB2B string) (*GetAccessTokenResponse, error)
+	CustomerAccessToken(ctx context.Context, key CustomerTokenKey, authCode string) (string, error)
+	InvalidateCustomerAccessToken(ctx context.Context, key CustomerTokenKey) error
 	Debit(ctx context.Context, req *DebitRequest, b2bToken, b2b2cToken, externalID string) (*DebitResponse, error)
+	SafeDebit(ctx context.Context, req *DebitRequest, b2bToken, b2b2cToken, externalID string) (*DebitResponse, error)
 	Unbind(ctx context.Context, req *AccountUnbindRequest, b2bToken, b2b2cToken, externalID string) (*AccountUnbindResponse, error)
 	DebitStatus(ctx context.Context, b2bToken, debitExternalID, externalID string) (*DebitResponse, error)
+	VerifyOTP(ctx context.Context, req *VerifyOTPRequest, b2bToken, b2b2cToken, externalID string) (*VerifyOTPResponse, error)
+	GetCardList(ctx context.Context, req *GetCardsRequest, b2bToken, externalID string) (*GetCardListResponse, error)
+	Refund(ctx context.Context, req *RefundRequest, b2bToken, b2b2cToken, externalID string) (*RefundResponse, error)
+	RefundStatus(ctx context.Context, b2bToken, refundExternalID, externalID string) (*RefundResponse, error)
+	CancelDebit(ctx context.Context, req *CancelDebitRequest, b2bToken, b2b2cToken, externalID string) (*CancelDebitResponse, error)
 }
 
 // to check if the Client already satisfies the interface.
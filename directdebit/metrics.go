@@ -0,0 +1,92 @@
+package directdebit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Token kinds reported to Metrics.ObserveTokenRefresh.
+const (
+	TokenKindBusiness = "b2b"
+	TokenKindCustomer = "b2b2c"
+)
+
+// RequestObservation describes a single request attempt.
+type RequestObservation struct {
+	Operation string
+	Method    string
+	// Endpoint is the request path without its query string.
+	Endpoint string
+	// StatusCode is 0 when no response was received.
+	StatusCode   int
+	ResponseCode ResponseCode
+	Duration     time.Duration
+	Err          error
+}
+
+// Metrics receives measurements from the client. Implementations must be safe for
+// concurrent use. See the directdebitprom package for a Prometheus implementation.
+type Metrics interface {
+	// ObserveRequest is called after every request attempt.
+	ObserveRequest(ctx context.Context, obs RequestObservation)
+	// ObserveRetry is called before an operation is attempted again, attempt is the
+	// number of the failed attempt.
+	ObserveRetry(ctx context.Context, operation string, attempt int)
+	// ObserveTokenRefresh is called after an access token of kind, one of the
+	// TokenKind constants, is requested.
+	ObserveTokenRefresh(ctx context.Context, kind string, err error)
+}
+
+func (c Client) observeRequest(ctx context.Context, req *Request, start time.Time, resp *Response, err error) {
+	if c.Config.Metrics == nil {
+		return
+	}
+
+	endpoint, _, _ := strings.Cut(req.Path, "?")
+	obs := RequestObservation{
+		Operation: req.Operation,
+		Method:    req.Method,
+		Endpoint:  endpoint,
+		Duration:  time.Since(start),
+		Err:       err,
+	}
+
+	var respErr *ResponseError
+	switch {
+	case resp != nil:
+		obs.StatusCode = resp.StatusCode
+		obs.ResponseCode = peekResponseCode(resp.Body)
+	case errors.As(err, &respErr):
+		obs.StatusCode = respErr.StatusCode
+		obs.ResponseCode = respErr.ResponseCode
+	}
+
+	c.Config.Metrics.ObserveRequest(ctx, obs)
+}
+
+func (c Client) observeRetry(ctx context.Context, operation string, attempt int) {
+	if c.Config.Metrics != nil {
+		c.Config.Metrics.ObserveRetry(ctx, operation, attempt)
+	}
+}
+
+func (c Client) observeTokenRefresh(ctx context.Context, kind string, err error) {
+	if c.Config.Metrics != nil {
+		c.Config.Metrics.ObserveTokenRefresh(ctx, kind, err)
+	}
+}
+
+// peekResponseCode returns the responseCode of a SNAP JSON body, if any.
+func peekResponseCode(body []byte) ResponseCode {
+	var fields struct {
+		ResponseCode ResponseCode `json:"responseCode"`
+	}
+	if json.Unmarshal(body, &fields) != nil {
+		return ""
+	}
+
+	return fields.ResponseCode
+}
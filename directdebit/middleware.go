@@ -0,0 +1,53 @@
+package directdebit
+
+import (
+	"context"
+	"net/http"
+)
+
+// Request is a single signed attempt of an operation, as seen by middlewares.
+type Request struct {
+	// Operation is one of the Operation constants, or empty for requests sent with
+	// Execute directly. Those are sent once and have Attempt 1.
+	Operation string
+	Method    string
+	// Path is the endpoint including its query string.
+	Path   string
+	Header RequestHeader
+	// StringToSign is the string the X-SIGNATURE header was computed from.
+	StringToSign string
+	// ExtraHeader is added to the HTTP request after Header, it may be used to
+	// inject headers the SNAP headers do not cover.
+	ExtraHeader http.Header
+	Body        []byte
+	// Attempt counts the attempts of the operation, starting at 1.
+	Attempt int
+}
+
+// Response is a successful response to a Request. Failed responses are returned as
+// a *ResponseError instead.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Handler sends a Request.
+type Handler func(ctx context.Context, req *Request) (*Response, error)
+
+// Middleware wraps a Handler to add behaviour around every attempt sent by the
+// client, such as logging, tracing or metrics. Middlewares may change the request
+// before calling next, but the Header has already been signed, so changing its
+// fields invalidates the signature.
+type Middleware func(next Handler) Handler
+
+// handler returns the middleware chain ending with roundTrip. The first middleware
+// in Config.Middlewares is the outermost.
+func (c Client) handler() Handler {
+	h := Handler(c.roundTrip)
+	for i := len(c.Config.Middlewares) - 1; i >= 0; i-- {
+		h = c.Config.Middlewares[i](h)
+	}
+
+	return h
+}
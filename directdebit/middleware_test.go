@@ -0,0 +1,174 @@
+package directdebit_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit"
+)
+
+var _ = Describe("Middleware", func() {
+	var (
+		client      *directdebit.Client
+		cfg         *directdebit.Config
+		server      *httptest.Server
+		status      int
+		gotTraceID  string
+		serverCalls int
+	)
+
+	BeforeEach(func() {
+		status = http.StatusOK
+		serverCalls = 0
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			serverCalls++
+			gotTraceID = r.Header.Get("X-Trace-Id")
+			w.Header().Set("X-Request-Id", "requestID")
+			w.WriteHeader(status)
+			w.Write([]byte(`{"responseCode": "2025400", "responseMessage": "Successful"}`))
+		}))
+
+		cfg = &directdebit.Config{
+			ClientID:        "123",
+			MerchantID:      "123",
+			EndpointBaseURL: server.URL,
+			HTTPClient:      &http.Client{},
+			Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("receives the operation, signed request and response", func() {
+		var (
+			gotReq  directdebit.Request
+			gotResp *directdebit.Response
+		)
+		cfg.Middlewares = []directdebit.Middleware{
+			func(next directdebit.Handler) directdebit.Handler {
+				return func(ctx context.Context, req *directdebit.Request) (*directdebit.Response, error) {
+					gotReq = *req
+					resp, err := next(ctx, req)
+					gotResp = resp
+					return resp, err
+				}
+			},
+		}
+		client, _ = directdebit.New(cfg)
+
+		req := &directdebit.DebitRequest{PartnerReferenceNo: "ref"}
+		_, err := client.Debit(context.Background(), req, "b2bToken", "b2b2cToken", "externalID")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Expect(gotReq.Operation).Should(Equal(directdebit.OperationDebit))
+		Expect(gotReq.Method).Should(Equal(http.MethodPost))
+		Expect(gotReq.Path).Should(Equal(directdebit.DebitEndpoint))
+		Expect(gotReq.Header.ExternalID).Should(Equal("externalID"))
+		Expect(gotReq.Header.Signature).ShouldNot(BeEmpty())
+		Expect(string(gotReq.Body)).Should(ContainSubstring(`"partnerReferenceNo":"ref"`))
+		Expect(gotReq.Attempt).Should(Equal(1))
+		Expect(gotResp.StatusCode).Should(Equal(http.StatusOK))
+		Expect(gotResp.Header.Get("X-Request-Id")).Should(Equal("requestID"))
+	})
+
+	It("wraps requests sent with Execute", func() {
+		var got []directdebit.Request
+		cfg.Middlewares = []directdebit.Middleware{
+			func(next directdebit.Handler) directdebit.Handler {
+				return func(ctx context.Context, req *directdebit.Request) (*directdebit.Response, error) {
+					got = append(got, *req)
+					return next(ctx, req)
+				}
+			},
+		}
+		client, _ = directdebit.New(cfg)
+
+		_, err := client.Execute(context.Background(), http.MethodGet, directdebit.DebitStatusEndpoint, directdebit.RequestHeader{}, nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(got).Should(HaveLen(1))
+		Expect(got[0].Operation).Should(BeEmpty())
+		Expect(got[0].Path).Should(Equal(directdebit.DebitStatusEndpoint))
+		Expect(got[0].Attempt).Should(Equal(1))
+	})
+
+	It("runs middlewares in order", func() {
+		var order []string
+		record := func(name string) directdebit.Middleware {
+			return func(next directdebit.Handler) directdebit.Handler {
+				return func(ctx context.Context, req *directdebit.Request) (*directdebit.Response, error) {
+					order = append(order, name+" before")
+					resp, err := next(ctx, req)
+					order = append(order, name+" after")
+					return resp, err
+				}
+			}
+		}
+		cfg.Middlewares = []directdebit.Middleware{record("outer"), record("inner")}
+		client, _ = directdebit.New(cfg)
+
+		_, err := client.DebitStatus(context.Background(), "b2bToken", "debitExternalID", "externalID")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(order).Should(Equal([]string{"outer before", "inner before", "inner after", "outer after"}))
+	})
+
+	It("may inject headers", func() {
+		cfg.Middlewares = []directdebit.Middleware{
+			func(next directdebit.Handler) directdebit.Handler {
+				return func(ctx context.Context, req *directdebit.Request) (*directdebit.Response, error) {
+					req.ExtraHeader = http.Header{"X-Trace-Id": {"trace"}}
+					return next(ctx, req)
+				}
+			},
+		}
+		client, _ = directdebit.New(cfg)
+
+		_, err := client.DebitStatus(context.Background(), "b2bToken", "debitExternalID", "externalID")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(gotTraceID).Should(Equal("trace"))
+	})
+
+	It("may short circuit the request", func() {
+		blocked := errors.New("blocked")
+		cfg.Middlewares = []directdebit.Middleware{
+			func(next directdebit.Handler) directdebit.Handler {
+				return func(ctx context.Context, req *directdebit.Request) (*directdebit.Response, error) {
+					return nil, blocked
+				}
+			},
+		}
+		client, _ = directdebit.New(cfg)
+
+		_, err := client.DebitStatus(context.Background(), "b2bToken", "debitExternalID", "externalID")
+		Expect(err).Should(MatchError(blocked))
+		Expect(serverCalls).Should(Equal(0))
+	})
+
+	It("sees every attempt of a retried request", func() {
+		status = http.StatusServiceUnavailable
+		var attempts []int
+		cfg.RetryPolicy = &directdebit.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+		cfg.Middlewares = []directdebit.Middleware{
+			func(next directdebit.Handler) directdebit.Handler {
+				return func(ctx context.Context, req *directdebit.Request) (*directdebit.Response, error) {
+					attempts = append(attempts, req.Attempt)
+					return next(ctx, req)
+				}
+			},
+		}
+		client, _ = directdebit.New(cfg)
+
+		_, err := client.DebitStatus(context.Background(), "b2bToken", "debitExternalID", "externalID")
+		Expect(err).Should(HaveOccurred())
+		Expect(attempts).Should(Equal([]int{1, 2, 3}))
+	})
+})
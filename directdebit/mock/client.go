@@ -0,0 +1,265 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit (interfaces: ClientInterface)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mock/client.go -package=mock github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit ClientInterface
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	directdebit "github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockClientInterface is a mock of ClientInterface interface.
+type MockClientInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockClientInterfaceMockRecorder
+}
+
+// MockClientInterfaceMockRecorder is the mock recorder for MockClientInterface.
+type MockClientInterfaceMockRecorder struct {
+	mock *MockClientInterface
+}
+
+// NewMockClientInterface creates a new mock instance.
+func NewMockClientInterface(ctrl *gomock.Controller) *MockClientInterface {
+	mock := &MockClientInterface{ctrl: ctrl}
+	mock.recorder = &MockClientInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockClientInterface) EXPECT() *MockClientInterfaceMockRecorder {
+	return m.recorder
+}
+
+// AccountBinding mocks base method.
+func (m *MockClientInterface) AccountBinding(arg0 context.Context, arg1 *directdebit.AccountBindingRequest, arg2, arg3 string) (*directdebit.AccountBindingResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AccountBinding", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*directdebit.AccountBindingResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AccountBinding indicates an expected call of AccountBinding.
+func (mr *MockClientInterfaceMockRecorder) AccountBinding(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AccountBinding", reflect.TypeOf((*MockClientInterface)(nil).AccountBinding), arg0, arg1, arg2, arg3)
+}
+
+// CancelDebit mocks base method.
+func (m *MockClientInterface) CancelDebit(arg0 context.Context, arg1 *directdebit.CancelDebitRequest, arg2, arg3, arg4 string) (*directdebit.CancelDebitResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelDebit", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(*directdebit.CancelDebitResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelDebit indicates an expected call of CancelDebit.
+func (mr *MockClientInterfaceMockRecorder) CancelDebit(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelDebit", reflect.TypeOf((*MockClientInterface)(nil).CancelDebit), arg0, arg1, arg2, arg3, arg4)
+}
+
+// CustomerAccessToken mocks base method.
+func (m *MockClientInterface) CustomerAccessToken(arg0 context.Context, arg1 directdebit.CustomerTokenKey, arg2 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CustomerAccessToken", arg0, arg1, arg2)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CustomerAccessToken indicates an expected call of CustomerAccessToken.
+func (mr *MockClientInterfaceMockRecorder) CustomerAccessToken(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CustomerAccessToken", reflect.TypeOf((*MockClientInterface)(nil).CustomerAccessToken), arg0, arg1, arg2)
+}
+
+// Debit mocks base method.
+func (m *MockClientInterface) Debit(arg0 context.Context, arg1 *directdebit.DebitRequest, arg2, arg3, arg4 string) (*directdebit.DebitResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Debit", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(*directdebit.DebitResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Debit indicates an expected call of Debit.
+func (mr *MockClientInterfaceMockRecorder) Debit(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Debit", reflect.TypeOf((*MockClientInterface)(nil).Debit), arg0, arg1, arg2, arg3, arg4)
+}
+
+// DebitStatus mocks base method.
+func (m *MockClientInterface) DebitStatus(arg0 context.Context, arg1, arg2, arg3 string) (*directdebit.DebitResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DebitStatus", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*directdebit.DebitResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DebitStatus indicates an expected call of DebitStatus.
+func (mr *MockClientInterfaceMockRecorder) DebitStatus(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DebitStatus", reflect.TypeOf((*MockClientInterface)(nil).DebitStatus), arg0, arg1, arg2, arg3)
+}
+
+// GetAuthCode mocks base method.
+func (m *MockClientInterface) GetAuthCode(arg0 context.Context, arg1 *directdebit.GetAuthCodeRequest, arg2, arg3 string) (*directdebit.GetAuthCodeResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuthCode", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*directdebit.GetAuthCodeResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuthCode indicates an expected call of GetAuthCode.
+func (mr *MockClientInterfaceMockRecorder) GetAuthCode(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuthCode", reflect.TypeOf((*MockClientInterface)(nil).GetAuthCode), arg0, arg1, arg2, arg3)
+}
+
+// GetBusinessAccessToken mocks base method.
+func (m *MockClientInterface) GetBusinessAccessToken(arg0 context.Context) (*directdebit.GetAccessTokenResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBusinessAccessToken", arg0)
+	ret0, _ := ret[0].(*directdebit.GetAccessTokenResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBusinessAccessToken indicates an expected call of GetBusinessAccessToken.
+func (mr *MockClientInterfaceMockRecorder) GetBusinessAccessToken(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBusinessAccessToken", reflect.TypeOf((*MockClientInterface)(nil).GetBusinessAccessToken), arg0)
+}
+
+// GetCardList mocks base method.
+func (m *MockClientInterface) GetCardList(arg0 context.Context, arg1 *directdebit.GetCardsRequest, arg2, arg3 string) (*directdebit.GetCardListResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCardList", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*directdebit.GetCardListResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCardList indicates an expected call of GetCardList.
+func (mr *MockClientInterfaceMockRecorder) GetCardList(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCardList", reflect.TypeOf((*MockClientInterface)(nil).GetCardList), arg0, arg1, arg2, arg3)
+}
+
+// GetCustomerAccessToken mocks base method.
+func (m *MockClientInterface) GetCustomerAccessToken(arg0 context.Context, arg1, arg2 string) (*directdebit.GetAccessTokenResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCustomerAccessToken", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*directdebit.GetAccessTokenResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCustomerAccessToken indicates an expected call of GetCustomerAccessToken.
+func (mr *MockClientInterfaceMockRecorder) GetCustomerAccessToken(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCustomerAccessToken", reflect.TypeOf((*MockClientInterface)(nil).GetCustomerAccessToken), arg0, arg1, arg2)
+}
+
+// InvalidateCustomerAccessToken mocks base method.
+func (m *MockClientInterface) InvalidateCustomerAccessToken(arg0 context.Context, arg1 directdebit.CustomerTokenKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InvalidateCustomerAccessToken", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InvalidateCustomerAccessToken indicates an expected call of InvalidateCustomerAccessToken.
+func (mr *MockClientInterfaceMockRecorder) InvalidateCustomerAccessToken(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateCustomerAccessToken", reflect.TypeOf((*MockClientInterface)(nil).InvalidateCustomerAccessToken), arg0, arg1)
+}
+
+// Refund mocks base method.
+func (m *MockClientInterface) Refund(arg0 context.Context, arg1 *directdebit.RefundRequest, arg2, arg3, arg4 string) (*directdebit.RefundResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refund", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(*directdebit.RefundResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Refund indicates an expected call of Refund.
+func (mr *MockClientInterfaceMockRecorder) Refund(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refund", reflect.TypeOf((*MockClientInterface)(nil).Refund), arg0, arg1, arg2, arg3, arg4)
+}
+
+// RefundStatus mocks base method.
+func (m *MockClientInterface) RefundStatus(arg0 context.Context, arg1, arg2, arg3 string) (*directdebit.RefundResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefundStatus", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*directdebit.RefundResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefundStatus indicates an expected call of RefundStatus.
+func (mr *MockClientInterfaceMockRecorder) RefundStatus(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefundStatus", reflect.TypeOf((*MockClientInterface)(nil).RefundStatus), arg0, arg1, arg2, arg3)
+}
+
+// SafeDebit mocks base method.
+func (m *MockClientInterface) SafeDebit(arg0 context.Context, arg1 *directdebit.DebitRequest, arg2, arg3, arg4 string) (*directdebit.DebitResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SafeDebit", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(*directdebit.DebitResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SafeDebit indicates an expected call of SafeDebit.
+func (mr *MockClientInterfaceMockRecorder) SafeDebit(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SafeDebit", reflect.TypeOf((*MockClientInterface)(nil).SafeDebit), arg0, arg1, arg2, arg3, arg4)
+}
+
+// Unbind mocks base method.
+func (m *MockClientInterface) Unbind(arg0 context.Context, arg1 *directdebit.AccountUnbindRequest, arg2, arg3, arg4 string) (*directdebit.AccountUnbindResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unbind", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(*directdebit.AccountUnbindResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Unbind indicates an expected call of Unbind.
+func (mr *MockClientInterfaceMockRecorder) Unbind(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unbind", reflect.TypeOf((*MockClientInterface)(nil).Unbind), arg0, arg1, arg2, arg3, arg4)
+}
+
+// VerifyOTP mocks base method.
+func (m *MockClientInterface) VerifyOTP(arg0 context.Context, arg1 *directdebit.VerifyOTPRequest, arg2, arg3, arg4 string) (*directdebit.VerifyOTPResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyOTP", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(*directdebit.VerifyOTPResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyOTP indicates an expected call of VerifyOTP.
+func (mr *MockClientInterfaceMockRecorder) VerifyOTP(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyOTP", reflect.TypeOf((*MockClientInterface)(nil).VerifyOTP), arg0, arg1, arg2, arg3, arg4)
+}
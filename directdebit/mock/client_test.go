@@ -0,0 +1,58 @@
+package mock_test
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/mock/gomock"
+
+	"github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit"
+	"github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit/mock"
+)
+
+var _ directdebit.ClientInterface = (*mock.MockClientInterface)(nil)
+
+func TestMockClientInterface(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mock.NewMockClientInterface(ctrl)
+
+	client.EXPECT().
+		Debit(gomock.Any(), gomock.Any(), "b2bToken", "b2b2cToken", "externalID").
+		Return(&directdebit.DebitResponse{ResponseCode: "2005400"}, nil)
+
+	var api directdebit.ClientInterface = client
+	resp, err := api.Debit(context.Background(), &directdebit.DebitRequest{}, "b2bToken", "b2b2cToken", "externalID")
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	if !resp.ResponseCode.IsSuccess() {
+		t.Errorf("Expected the stubbed response, got %+v", resp)
+	}
+}
+
+func TestMockDrivesDebitFlow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mock.NewMockClientInterface(ctrl)
+
+	card := directdebit.DebitCard{PublicUserID: "user", AccountToken: "card"}
+	client.EXPECT().
+		CustomerAccessToken(gomock.Any(), directdebit.CustomerTokenKey{PublicUserID: "user", AccountToken: "card"}, "").
+		Return("b2b2cToken", nil)
+	client.EXPECT().
+		SafeDebit(gomock.Any(), gomock.Any(), "", "b2b2cToken", gomock.Any()).
+		Return(&directdebit.DebitResponse{ResponseCode: "2005400", ReferenceNo: "ref"}, nil)
+
+	flow := directdebit.NewDebitFlow(client)
+	result, err := flow.Start(context.Background(), &directdebit.DebitFlowRequest{
+		Card:   card,
+		Amount: directdebit.Amount{Value: "10000.00", Currency: "IDR"},
+	})
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	if result.Step != directdebit.DebitStepCompleted || result.ReferenceNo != "ref" {
+		t.Errorf("Expected a completed debit, got %+v", result)
+	}
+}
@@ -0,0 +1,135 @@
+package directdebit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// MaxNotificationBodySize is the largest notification body NotificationHandler
+// reads. Larger requests are answered with 413 Request Entity Too Large.
+const MaxNotificationBodySize = 64 << 10
+
+var (
+	notificationAcknowledged   = NotificationAcknowledgement{ResponseCode: "2005600", ResponseMessage: "Successful"}
+	notificationInvalidPayload = NotificationAcknowledgement{ResponseCode: "4005601", ResponseMessage: "Invalid Field Format"}
+	notificationUnauthorized   = NotificationAcknowledgement{ResponseCode: "4015600", ResponseMessage: "Unauthorized. Invalid Signature"}
+	notificationNotAllowed     = NotificationAcknowledgement{ResponseCode: "4055600", ResponseMessage: "Requested Function Is Not Supported"}
+	notificationTooLarge       = NotificationAcknowledgement{ResponseCode: "4135600", ResponseMessage: "Request Entity Too Large"}
+	notificationFailed         = NotificationAcknowledgement{ResponseCode: "5005600", ResponseMessage: "General Error"}
+)
+
+// NotificationCallback receives verified debit notifications. Returning an error
+// makes the handler answer with a server error so that Ayoconnect retries the
+// notification.
+type NotificationCallback func(ctx context.Context, notification *DebitNotification) error
+
+// NotificationHandler is an http.Handler for the asynchronous debit result
+// notifications pushed by Ayoconnect. It verifies X-SIGNATURE and X-TIMESTAMP, with
+// Config.AyoconnectPublicKey when it is set and with the client secret otherwise,
+// passes the parsed notification to the callback and writes the acknowledgement
+// body Ayoconnect expects.
+type NotificationHandler struct {
+	config   *Config
+	callback NotificationCallback
+}
+
+func NewNotificationHandler(c *Config, callback NotificationCallback) *NotificationHandler {
+	return &NotificationHandler{
+		config:   c,
+		callback: callback,
+	}
+}
+
+func (h *NotificationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if r.Method != http.MethodPost {
+		h.reply(w, http.StatusMethodNotAllowed, notificationNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxNotificationBodySize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.log(ctx, "rejected debit notification", err)
+			h.reply(w, http.StatusRequestEntityTooLarge, notificationTooLarge)
+			return
+		}
+
+		h.reply(w, http.StatusBadRequest, notificationInvalidPayload)
+		return
+	}
+
+	err = h.verify(r, body)
+	if err != nil {
+		h.log(ctx, "rejected debit notification", err)
+		h.reply(w, http.StatusUnauthorized, notificationUnauthorized)
+		return
+	}
+
+	notification := DebitNotification{}
+	err = json.Unmarshal(body, &notification)
+	if err != nil {
+		h.log(ctx, "failed to parse debit notification", err)
+		h.reply(w, http.StatusBadRequest, notificationInvalidPayload)
+		return
+	}
+
+	err = h.callback(ctx, &notification)
+	if err != nil {
+		h.log(ctx, "failed to handle debit notification", err)
+		h.reply(w, http.StatusInternalServerError, notificationFailed)
+		return
+	}
+
+	h.reply(w, http.StatusOK, notificationAcknowledged)
+}
+
+func (h *NotificationHandler) verify(r *http.Request, body []byte) error {
+	if h.config.AyoconnectPublicKey != "" {
+		return VerifyRSARequestSignature(
+			r.Header.Get("X-SIGNATURE"),
+			r.Method,
+			r.URL.Path,
+			string(body),
+			r.Header.Get("X-TIMESTAMP"),
+			h.config.AyoconnectPublicKey,
+			h.config.MaxClockSkew,
+		)
+	}
+
+	accessToken := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	return VerifyHmacSignature(
+		r.Header.Get("X-SIGNATURE"),
+		r.Method,
+		r.URL.Path,
+		accessToken,
+		string(body),
+		r.Header.Get("X-TIMESTAMP"),
+		h.config.ClientSecret,
+		h.config.MaxClockSkew,
+	)
+}
+
+func (h *NotificationHandler) reply(w http.ResponseWriter, status int, ack NotificationAcknowledgement) {
+	body, _ := json.Marshal(ack)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+func (h *NotificationHandler) log(ctx context.Context, msg string, err error) {
+	if h.config.Logger == nil {
+		return
+	}
+
+	h.config.Logger.ErrorContext(ctx, msg, slog.String("error", err.Error()))
+}
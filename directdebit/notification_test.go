@@ -0,0 +1,197 @@
+package directdebit_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit"
+)
+
+var _ = Describe("NotificationHandler", func() {
+	var (
+		cfg         *directdebit.Config
+		handler     *directdebit.NotificationHandler
+		received    *directdebit.DebitNotification
+		callbackErr error
+		body        string
+		path        string
+	)
+
+	newRequest := func(method, body, timestamp, signature string) *http.Request {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("X-TIMESTAMP", timestamp)
+		req.Header.Set("X-SIGNATURE", signature)
+		req.Header.Set("Authorization", "Bearer notifyToken")
+		return req
+	}
+
+	sign := func(body, timestamp string) string {
+		return directdebit.GenerateHmacSignature("POST", path, "notifyToken", body, timestamp, "secret")
+	}
+
+	serve := func(req *http.Request) (*httptest.ResponseRecorder, directdebit.NotificationAcknowledgement) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		ack := directdebit.NotificationAcknowledgement{}
+		_ = json.Unmarshal(rec.Body.Bytes(), &ack)
+		return rec, ack
+	}
+
+	BeforeEach(func() {
+		cfg = &directdebit.Config{
+			ClientID:     "123",
+			ClientSecret: "secret",
+			MerchantID:   "123",
+			Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+		}
+
+		received = nil
+		callbackErr = nil
+		path = "/callback/debit"
+		body = `{
+			"responseCode": "2005600",
+			"responseMessage": "Successful",
+			"originalPartnerReferenceNo": "t4tn57kibeunbam9dtr89urv8h2jbem9",
+			"originalReferenceNo": "ref",
+			"latestTransactionStatus": "00",
+			"transactionStatusDesc": "success",
+			"amount": {"value": "1000000", "currency": "IDR"},
+			"additionalInfo": {"publicUserId": "TEST", "paymentResult": "success"}
+		}`
+
+		handler = directdebit.NewNotificationHandler(cfg, func(ctx context.Context, n *directdebit.DebitNotification) error {
+			received = n
+			return callbackErr
+		})
+	})
+
+	When("the notification is correctly signed", func() {
+		It("dispatches the notification and acknowledges it", func() {
+			timestamp := time.Now().Format(time.RFC3339)
+			rec, ack := serve(newRequest(http.MethodPost, body, timestamp, sign(body, timestamp)))
+
+			Expect(rec.Code).Should(Equal(http.StatusOK))
+			Expect(ack.ResponseCode).Should(BeEquivalentTo("2005600"))
+			Expect(received).ShouldNot(BeNil())
+			Expect(received.OriginalPartnerReferenceNo).Should(Equal("t4tn57kibeunbam9dtr89urv8h2jbem9"))
+			Expect(received.LatestTransactionStatus).Should(Equal("00"))
+			Expect(received.Amount).Should(Equal(directdebit.Amount{Value: "1000000", Currency: "IDR"}))
+			Expect(received.AdditionalInfo.PaymentResult).Should(Equal("success"))
+		})
+
+		It("returns a server error when the callback fails", func() {
+			callbackErr = errors.New("database unavailable")
+			timestamp := time.Now().Format(time.RFC3339)
+			rec, ack := serve(newRequest(http.MethodPost, body, timestamp, sign(body, timestamp)))
+
+			Expect(rec.Code).Should(Equal(http.StatusInternalServerError))
+			Expect(ack.ResponseCode).Should(BeEquivalentTo("5005600"))
+		})
+
+		It("rejects a payload that is not valid JSON", func() {
+			body = "invalid json"
+			timestamp := time.Now().Format(time.RFC3339)
+			rec, ack := serve(newRequest(http.MethodPost, body, timestamp, sign(body, timestamp)))
+
+			Expect(rec.Code).Should(Equal(http.StatusBadRequest))
+			Expect(ack.ResponseCode).Should(BeEquivalentTo("4005601"))
+			Expect(received).Should(BeNil())
+		})
+	})
+
+	When("the signature does not match", func() {
+		It("rejects the notification", func() {
+			timestamp := time.Now().Format(time.RFC3339)
+			rec, ack := serve(newRequest(http.MethodPost, body, timestamp, sign(`{"tampered":true}`, timestamp)))
+
+			Expect(rec.Code).Should(Equal(http.StatusUnauthorized))
+			Expect(ack.ResponseCode).Should(BeEquivalentTo("4015600"))
+			Expect(received).Should(BeNil())
+		})
+	})
+
+	When("the notification is signed with the Ayoconnect private key", func() {
+		BeforeEach(func() {
+			cfg.AyoconnectPublicKey = verifyPublicKey
+		})
+
+		signRSA := func(body, timestamp string) string {
+			signature, err := directdebit.GenerateRSARequestSignature(http.MethodPost, path, body, timestamp, verifyPrivateKey)
+			Expect(err).ShouldNot(HaveOccurred())
+			return signature
+		}
+
+		It("dispatches the notification", func() {
+			timestamp := time.Now().Format(time.RFC3339)
+			rec, _ := serve(newRequest(http.MethodPost, body, timestamp, signRSA(body, timestamp)))
+
+			Expect(rec.Code).Should(Equal(http.StatusOK))
+			Expect(received.OriginalReferenceNo).Should(Equal("ref"))
+		})
+
+		It("rejects the signature replayed with another body", func() {
+			timestamp := time.Now().Format(time.RFC3339)
+			rec, _ := serve(newRequest(http.MethodPost, `{"tampered":true}`, timestamp, signRSA(body, timestamp)))
+
+			Expect(rec.Code).Should(Equal(http.StatusUnauthorized))
+			Expect(received).Should(BeNil())
+		})
+
+		It("rejects an HMAC signature", func() {
+			timestamp := time.Now().Format(time.RFC3339)
+			rec, _ := serve(newRequest(http.MethodPost, body, timestamp, sign(body, timestamp)))
+
+			Expect(rec.Code).Should(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	When("the timestamp is outside the allowed clock skew", func() {
+		It("rejects the notification", func() {
+			timestamp := time.Now().Add(-10 * time.Minute).Format(time.RFC3339)
+			rec, _ := serve(newRequest(http.MethodPost, body, timestamp, sign(body, timestamp)))
+
+			Expect(rec.Code).Should(Equal(http.StatusUnauthorized))
+			Expect(received).Should(BeNil())
+		})
+
+		It("accepts the notification when the configured skew allows it", func() {
+			cfg.MaxClockSkew = time.Hour
+			timestamp := time.Now().Add(-10 * time.Minute).Format(time.RFC3339)
+			rec, _ := serve(newRequest(http.MethodPost, body, timestamp, sign(body, timestamp)))
+
+			Expect(rec.Code).Should(Equal(http.StatusOK))
+		})
+	})
+
+	When("the body is larger than MaxNotificationBodySize", func() {
+		It("rejects the notification", func() {
+			body = `{"responseMessage": "` + strings.Repeat("x", directdebit.MaxNotificationBodySize) + `"}`
+			timestamp := time.Now().Format(time.RFC3339)
+			rec, ack := serve(newRequest(http.MethodPost, body, timestamp, sign(body, timestamp)))
+
+			Expect(rec.Code).Should(Equal(http.StatusRequestEntityTooLarge))
+			Expect(ack.ResponseCode).Should(BeEquivalentTo("4135600"))
+			Expect(received).Should(BeNil())
+		})
+	})
+
+	When("the request is not a POST", func() {
+		It("rejects the request", func() {
+			rec, _ := serve(newRequest(http.MethodGet, "", "", ""))
+
+			Expect(rec.Code).Should(Equal(http.StatusMethodNotAllowed))
+		})
+	})
+})
@@ -0,0 +1,53 @@
+package directdebit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// VerifyOTP completes a binding, unbinding or debit that requires OTP verification.
+// Set req.Action to one of the OTPAction constants and req.OriginalReferenceNo to
+// the reference number of the transaction being verified. Binding OTP does not
+// need a b2b2cToken.
+func (c Client) VerifyOTP(
+	ctx context.Context,
+	req *VerifyOTPRequest,
+	b2bToken,
+	b2b2cToken,
+	externalID string,
+) (*VerifyOTPResponse, error) {
+	b2bToken, err := c.resolveB2BToken(ctx, b2bToken)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := VerifyOTPEndpoint
+
+	if req.MerchantID == "" {
+		req.MerchantID = c.Config.MerchantID
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.send(ctx, &call{
+		operation: OperationVerifyOTP,
+		method:    http.MethodPost,
+		path:      endpoint,
+		body:      body,
+		sign:      c.hmacHeaders(http.MethodPost, endpoint, body, b2bToken, b2b2cToken, externalID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	respEntity := VerifyOTPResponse{}
+	err = json.Unmarshal(resp, &respEntity)
+	if err != nil {
+		return nil, err
+	}
+
+	return &respEntity, nil
+}
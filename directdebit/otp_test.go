@@ -0,0 +1,122 @@
+package directdebit_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit"
+)
+
+var _ = Describe("VerifyOTP", func() {
+	var (
+		client     *directdebit.Client
+		cfg        *directdebit.Config
+		server     *httptest.Server
+		request    *directdebit.VerifyOTPRequest
+		b2bToken   string
+		b2b2cToken string
+		externalID string
+	)
+
+	BeforeEach(func() {
+		cfg = &directdebit.Config{
+			ClientID:     "123",
+			ClientSecret: "secret",
+			MerchantID:   "123",
+			HTTPClient:   &http.Client{},
+		}
+
+		request = &directdebit.VerifyOTPRequest{
+			PartnerReferenceNo:  "partnerReferenceNo",
+			OriginalReferenceNo: "referenceNo",
+			Action:              directdebit.OTPActionPayment,
+			OTP:                 "123456",
+		}
+		b2bToken = "sampleB2bToken"
+		b2b2cToken = "sampleB2b2cToken"
+		externalID = "sampleExternalID"
+	})
+
+	AfterEach(func() {
+		if server != nil {
+			server.Close()
+		}
+	})
+
+	When("verify otp error", func() {
+		It("return error when failed to execute http", func() {
+			client, _ = directdebit.New(cfg)
+			resp, err := client.VerifyOTP(context.Background(), request, b2bToken, b2b2cToken, externalID)
+			Expect(err).To(HaveOccurred())
+			Expect(resp).To(BeNil())
+		})
+
+		It("return error when failed to unmarshal JSON", func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`invalid json`))
+			}))
+
+			cfg.EndpointBaseURL = server.URL
+			client, _ = directdebit.New(cfg)
+
+			resp, err := client.VerifyOTP(context.Background(), request, b2bToken, b2b2cToken, externalID)
+			Expect(err).To(HaveOccurred())
+			Expect(resp).To(BeNil())
+		})
+	})
+
+	When("verify otp successful", func() {
+		It("sends a signed request and returns correct response", func() {
+			var (
+				gotBody      []byte
+				gotSignature string
+				gotTimestamp string
+				gotCustomer  string
+			)
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotBody, _ = io.ReadAll(r.Body)
+				gotSignature = r.Header.Get("X-SIGNATURE")
+				gotTimestamp = r.Header.Get("X-TIMESTAMP")
+				gotCustomer = r.Header.Get("Authorization-Customer")
+
+				respJSON, _ := json.Marshal(directdebit.VerifyOTPResponse{
+					ResponseCode:        "2000400",
+					ResponseMessage:     "success",
+					PartnerReferenceNo:  "partnerReferenceNo",
+					OriginalReferenceNo: "referenceNo",
+					AdditionalInfo: directdebit.VerifyOTPResponseAdditionalInfo{
+						PaymentResult: "success",
+					},
+				})
+				w.WriteHeader(http.StatusOK)
+				w.Write(respJSON)
+			}))
+
+			cfg.EndpointBaseURL = server.URL
+			cfg.MerchantID = "MEKARI"
+			client, _ = directdebit.New(cfg)
+
+			resp, err := client.VerifyOTP(context.Background(), request, b2bToken, b2b2cToken, externalID)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(resp.AdditionalInfo.PaymentResult).Should(Equal("success"))
+			Expect(request.MerchantID).Should(Equal("MEKARI"))
+			Expect(gotCustomer).Should(Equal("Bearer " + b2b2cToken))
+
+			sentRequest := directdebit.VerifyOTPRequest{}
+			Expect(json.Unmarshal(gotBody, &sentRequest)).Should(Succeed())
+			Expect(sentRequest.Action).Should(Equal(directdebit.OTPActionPayment))
+			Expect(sentRequest.OTP).Should(Equal("123456"))
+
+			expectedSignature := directdebit.GenerateHmacSignature("POST", directdebit.VerifyOTPEndpoint, b2bToken, string(gotBody), gotTimestamp, "secret")
+			Expect(gotSignature).Should(Equal(expectedSignature))
+		})
+	})
+})
@@ -0,0 +1,212 @@
+package directdebit
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rsa"
+	"crypto/sha1" // #nosec PBKDF2 with HMAC-SHA1 is the PKCS#8 default and still widely produced
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// maxPBKDF2Iterations bounds the iteration count read from an encrypted key, so that
+// a malformed key cannot stall New. OpenSSL uses 2048 by default.
+const maxPBKDF2Iterations = 10_000_000
+
+var (
+	ErrMissingPrivateKey        = errors.New("neither Signer nor RsaPrivateKey is configured")
+	ErrMissingPassphrase        = errors.New("private key is encrypted but no passphrase is configured")
+	ErrIncorrectPassphrase      = errors.New("failed to decrypt private key, the passphrase may be incorrect")
+	ErrUnsupportedKeyEncryption = errors.New("unsupported private key encryption, only PBES2 with PBKDF2 and AES-CBC is supported")
+)
+
+var (
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES128CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     algorithmIdentifier
+	EncryptedData []byte
+}
+
+type algorithmIdentifier struct {
+	Algorithm  asn1.ObjectIdentifier
+	Parameters asn1.RawValue `asn1:"optional"`
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc algorithmIdentifier
+	EncryptionScheme  algorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                 `asn1:"optional"`
+	PRF            algorithmIdentifier `asn1:"optional"`
+}
+
+// parseRSAPrivateKey parses a PEM encoded RSA private key in PKCS#1 ("RSA PRIVATE KEY")
+// or PKCS#8 ("PRIVATE KEY") form. Keys encrypted with a passphrase, either as a
+// PKCS#8 "ENCRYPTED PRIVATE KEY" or a legacy PEM block with a DEK-Info header, are
+// decrypted with passphrase.
+func parseRSAPrivateKey(privkey string, passphrase string) (*rsa.PrivateKey, error) {
+	privKeyBlock, _ := pem.Decode([]byte(privkey))
+	if privKeyBlock == nil {
+		return nil, ErrParsePEMBlock
+	}
+
+	der := privKeyBlock.Bytes
+
+	//nolint:staticcheck // legacy encrypted PEM is still produced by "openssl rsa -aes128"
+	if x509.IsEncryptedPEMBlock(privKeyBlock) {
+		if passphrase == "" {
+			return nil, ErrMissingPassphrase
+		}
+
+		//nolint:staticcheck // see above
+		decrypted, err := x509.DecryptPEMBlock(privKeyBlock, []byte(passphrase))
+		if err != nil {
+			return nil, ErrIncorrectPassphrase
+		}
+		der = decrypted
+	}
+
+	switch privKeyBlock.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(der)
+	case "ENCRYPTED PRIVATE KEY":
+		if passphrase == "" {
+			return nil, ErrMissingPassphrase
+		}
+
+		decrypted, err := decryptPKCS8PrivateKey(der, []byte(passphrase))
+		if err != nil {
+			return nil, err
+		}
+		der = decrypted
+	}
+
+	privateKey, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		// some tools write PKCS#1 keys with a "PRIVATE KEY" header
+		if pkey, pkcs1Err := x509.ParsePKCS1PrivateKey(der); pkcs1Err == nil {
+			return pkey, nil
+		}
+		return nil, err
+	}
+
+	pkey, ok := privateKey.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: got %T", ErrNotRSAPrivateKey, privateKey)
+	}
+
+	return pkey, nil
+}
+
+// decryptPKCS8PrivateKey decrypts a PKCS#8 EncryptedPrivateKeyInfo that uses PBES2
+// with PBKDF2 and AES-CBC, which is what OpenSSL produces by default.
+func decryptPKCS8PrivateKey(der []byte, passphrase []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, err
+	}
+
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, ErrUnsupportedKeyEncryption
+	}
+
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, err
+	}
+
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, ErrUnsupportedKeyEncryption
+	}
+
+	var kdf pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		return nil, err
+	}
+
+	prf := sha1.New
+	switch {
+	case len(kdf.PRF.Algorithm) == 0, kdf.PRF.Algorithm.Equal(oidHMACWithSHA1):
+	case kdf.PRF.Algorithm.Equal(oidHMACWithSHA256):
+		prf = sha256.New
+	default:
+		return nil, ErrUnsupportedKeyEncryption
+	}
+
+	var keyLen int
+	switch {
+	case params.EncryptionScheme.Algorithm.Equal(oidAES128CBC):
+		keyLen = 16
+	case params.EncryptionScheme.Algorithm.Equal(oidAES192CBC):
+		keyLen = 24
+	case params.EncryptionScheme.Algorithm.Equal(oidAES256CBC):
+		keyLen = 32
+	default:
+		return nil, ErrUnsupportedKeyEncryption
+	}
+
+	if kdf.KeyLength != 0 && kdf.KeyLength != keyLen {
+		return nil, fmt.Errorf("%w: key length %d does not match the cipher", ErrUnsupportedKeyEncryption, kdf.KeyLength)
+	}
+
+	if kdf.IterationCount < 1 || kdf.IterationCount > maxPBKDF2Iterations {
+		return nil, fmt.Errorf("%w: iteration count %d", ErrUnsupportedKeyEncryption, kdf.IterationCount)
+	}
+
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, err
+	}
+
+	key := pbkdf2.Key(passphrase, kdf.Salt, kdf.IterationCount, keyLen, prf)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(iv) != block.BlockSize() || len(info.EncryptedData)%block.BlockSize() != 0 || len(info.EncryptedData) == 0 {
+		return nil, ErrIncorrectPassphrase
+	}
+
+	decrypted := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, info.EncryptedData)
+
+	return unpadPKCS7(decrypted, block.BlockSize())
+}
+
+func unpadPKCS7(data []byte, blockSize int) ([]byte, error) {
+	if len(data) == 0 || len(data)%blockSize != 0 {
+		return nil, ErrIncorrectPassphrase
+	}
+
+	padding := int(data[len(data)-1])
+	if padding == 0 || padding > blockSize {
+		return nil, ErrIncorrectPassphrase
+	}
+
+	for _, b := range data[len(data)-padding:] {
+		if int(b) != padding {
+			return nil, ErrIncorrectPassphrase
+		}
+	}
+
+	return data[:len(data)-padding], nil
+}
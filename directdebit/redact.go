@@ -0,0 +1,205 @@
+package directdebit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultRedactionMask replaces redacted values.
+const DefaultRedactionMask = "[REDACTED]"
+
+var (
+	// DefaultRedactedFields are the JSON fields and query parameters masked by
+	// DefaultRedactor.
+	DefaultRedactedFields = []string{
+		"accessToken",
+		"authCode",
+		"accountToken",
+		"bankCardToken",
+		"seamlessData",
+		"mobileNumber",
+		"phoneNumber",
+		"email",
+		"otp",
+		"otpToken",
+		"unlinkOtpToken",
+		"clientSecret",
+	}
+
+	// DefaultRedactedHeaders are the HTTP headers masked by DefaultRedactor.
+	DefaultRedactedHeaders = []string{
+		"Authorization",
+		"Authorization-Customer",
+		"X-SIGNATURE",
+	}
+)
+
+// Redactor masks sensitive values before request and response data is logged.
+//
+// A field without a dot, such as "accessToken", matches the key at any depth of a
+// JSON document and the query parameter of the same name. A field with dots, such
+// as "additionalInfo.publicUserId", only matches that path from the root of the
+// document, arrays are skipped in paths. Fields and headers are matched without
+// regard to case.
+type Redactor struct {
+	Fields  []string
+	Headers []string
+	// Mask replaces redacted values. Defaults to DefaultRedactionMask.
+	Mask string
+}
+
+// DefaultRedactor returns a Redactor masking DefaultRedactedFields and
+// DefaultRedactedHeaders. Append to its Fields to mask more fields.
+func DefaultRedactor() *Redactor {
+	return &Redactor{
+		Fields:  append([]string(nil), DefaultRedactedFields...),
+		Headers: append([]string(nil), DefaultRedactedHeaders...),
+	}
+}
+
+func (r *Redactor) mask() string {
+	if r.Mask == "" {
+		return DefaultRedactionMask
+	}
+
+	return r.Mask
+}
+
+func (r *Redactor) matchField(path []string) bool {
+	key := path[len(path)-1]
+	full := strings.Join(path, ".")
+	for _, field := range r.Fields {
+		if strings.Contains(field, ".") {
+			if strings.EqualFold(field, full) {
+				return true
+			}
+			continue
+		}
+
+		if strings.EqualFold(field, key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RedactJSON returns body with the values of sensitive fields masked. A body that is
+// not JSON is returned unchanged.
+func (r *Redactor) RedactJSON(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return string(body)
+	}
+
+	redacted, err := json.Marshal(r.redactValue(doc, nil))
+	if err != nil {
+		return string(body)
+	}
+
+	return string(redacted)
+}
+
+func (r *Redactor) redactValue(v any, path []string) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			fieldPath := append(path[:len(path):len(path)], key)
+			if r.matchField(fieldPath) {
+				v[key] = r.mask()
+				continue
+			}
+			v[key] = r.redactValue(value, fieldPath)
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = r.redactValue(value, path)
+		}
+	}
+
+	return v
+}
+
+// RedactPath returns path with the values of sensitive query parameters masked.
+func (r *Redactor) RedactPath(path string) string {
+	endpoint, query, ok := strings.Cut(path, "?")
+	if !ok {
+		return path
+	}
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return endpoint + "?" + r.mask()
+	}
+
+	for key := range values {
+		if r.matchField([]string{key}) {
+			values[key] = []string{r.mask()}
+		}
+	}
+
+	return endpoint + "?" + values.Encode()
+}
+
+// RedactHeader returns a copy of header with the values of sensitive headers masked.
+func (r *Redactor) RedactHeader(header http.Header) http.Header {
+	redacted := header.Clone()
+	for name := range redacted {
+		for _, sensitive := range r.Headers {
+			if strings.EqualFold(name, sensitive) {
+				redacted[name] = []string{r.mask()}
+				break
+			}
+		}
+	}
+
+	return redacted
+}
+
+// errorAttr returns err as a log attribute. A *ResponseError is logged as its status
+// and response code only, and the URL of a transport error has its query redacted.
+func (r *Redactor) errorAttr(err error) slog.Attr {
+	var respErr *ResponseError
+	if errors.As(err, &respErr) {
+		return slog.Group("error",
+			slog.Int("status_code", respErr.StatusCode),
+			slog.String("response_code", string(respErr.ResponseCode)),
+		)
+	}
+
+	return slog.String("error", r.RedactError(err))
+}
+
+// RedactError returns the message of err with the query of the URL of a transport
+// error redacted.
+func (r *Redactor) RedactError(err error) string {
+	msg := err.Error()
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		msg = strings.ReplaceAll(msg, strconv.Quote(urlErr.URL), strconv.Quote(r.RedactPath(urlErr.URL)))
+	}
+
+	return msg
+}
+
+func (c Client) redactor() *Redactor {
+	if c.Config.Redactor != nil {
+		return c.Config.Redactor
+	}
+
+	return DefaultRedactor()
+}
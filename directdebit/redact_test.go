@@ -0,0 +1,179 @@
+package directdebit_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit"
+)
+
+func TestRedactJSON(t *testing.T) {
+	redactor := directdebit.DefaultRedactor()
+	redactor.Fields = append(redactor.Fields, "additionalInfo.publicUserId")
+
+	tests := map[string]struct {
+		body string
+		want string
+	}{
+		"top level field": {
+			`{"accessToken": "secret", "expiresIn": 3599}`,
+			`{"accessToken":"[REDACTED]","expiresIn":3599}`,
+		},
+		"nested field": {
+			`{"additionalInfo": {"publicUserId": "user", "remarks": "ok"}, "cards": [{"bankCardToken": "card"}]}`,
+			`{"additionalInfo":{"publicUserId":"[REDACTED]","remarks":"ok"},"cards":[{"bankCardToken":"[REDACTED]"}]}`,
+		},
+		"path only matches from the root": {
+			`{"data": {"additionalInfo": {"publicUserId": "user"}}}`,
+			`{"data":{"additionalInfo":{"publicUserId":"user"}}}`,
+		},
+		"otp tokens": {
+			`{"additionalInfo": {"otpToken": "otp"}, "unlinkOtpToken": "unlink"}`,
+			`{"additionalInfo":{"otpToken":"[REDACTED]"},"unlinkOtpToken":"[REDACTED]"}`,
+		},
+		"case insensitive": {
+			`{"AuthCode": "code"}`,
+			`{"AuthCode":"[REDACTED]"}`,
+		},
+		"not json": {
+			`<html>Bad Gateway</html>`,
+			`<html>Bad Gateway</html>`,
+		},
+		"empty": {"", ""},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := redactor.RedactJSON([]byte(tc.body)); got != tc.want {
+				t.Errorf("Expected %s, but got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRedactPath(t *testing.T) {
+	redactor := &directdebit.Redactor{Fields: []string{"seamlessData"}, Mask: "***"}
+
+	got := redactor.RedactPath(`/api/v1.0/get-auth-code?merchantId=123&seamlessData=%7B%22mobileNumber%22%3A%22081%22%7D`)
+	if got != "/api/v1.0/get-auth-code?merchantId=123&seamlessData=%2A%2A%2A" {
+		t.Errorf("Unexpected path %s", got)
+	}
+
+	if got := redactor.RedactPath(directdebit.DebitEndpoint); got != directdebit.DebitEndpoint {
+		t.Errorf("Expected path without query to be unchanged, but got %s", got)
+	}
+}
+
+func TestRedactHeader(t *testing.T) {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer token")
+	header.Set("X-External-Id", "externalID")
+
+	redacted := directdebit.DefaultRedactor().RedactHeader(header)
+	if got := redacted.Get("Authorization"); got != directdebit.DefaultRedactionMask {
+		t.Errorf("Expected Authorization to be redacted, but got %s", got)
+	}
+
+	if got := redacted.Get("X-External-Id"); got != "externalID" {
+		t.Errorf("Expected X-External-Id to be kept, but got %s", got)
+	}
+
+	if header.Get("Authorization") != "Bearer token" {
+		t.Errorf("Expected the original header to be unchanged")
+	}
+}
+
+func TestExecuteRedactsLoggedFailure(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"responseCode": "4007401", "responseMessage": "Invalid Field Format", "accessToken": "leaked"}`))
+	}))
+	defer ts.Close()
+
+	var logs bytes.Buffer
+	client := directdebit.Client{
+		Config: &directdebit.Config{
+			EndpointBaseURL: ts.URL,
+			HTTPClient:      &http.Client{},
+			Logger:          slog.New(slog.NewJSONHandler(&logs, nil)),
+		},
+	}
+
+	headers := directdebit.RequestHeader{Authorization: "Bearer b2bToken", AuthorizationCustomer: "Bearer b2b2cToken"}
+	body := []byte(`{"grantType": "authorization_code", "authCode": "authCode"}`)
+	_, err := client.Execute(context.Background(), http.MethodPost, directdebit.GetCustomerAccessTokenEndpoint, headers, body)
+	if err == nil {
+		t.Fatalf("Expected an error")
+	}
+
+	for _, secret := range []string{"b2bToken", "b2b2cToken", `\"authCode\":\"authCode\"`, "leaked"} {
+		if strings.Contains(logs.String(), secret) {
+			t.Errorf("Expected %s to be redacted from %s", secret, logs.String())
+		}
+	}
+
+	if !strings.Contains(logs.String(), "Invalid Field Format") {
+		t.Errorf("Expected the response message to be logged, got %s", logs.String())
+	}
+}
+
+func TestRetryLogIsRedacted(t *testing.T) {
+	attempts := 0
+	var logs bytes.Buffer
+	redactor := directdebit.DefaultRedactor()
+	redactor.Fields = append(redactor.Fields, "XExternalId")
+
+	client := directdebit.Client{
+		Config: &directdebit.Config{
+			EndpointBaseURL: "http://ayoconnect.test",
+			ClientSecret:    "secret",
+			MerchantID:      "merchantID",
+			Redactor:        redactor,
+			Logger:          slog.New(slog.NewJSONHandler(&logs, nil)),
+			RetryPolicy:     &directdebit.RetryPolicy{MaxAttempts: 3},
+			HTTPClient: &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				attempts++
+				switch attempts {
+				case 1:
+					return nil, errors.New("connection reset")
+				case 2:
+					return &http.Response{
+						StatusCode: http.StatusInternalServerError,
+						Header:     http.Header{},
+						Body:       io.NopCloser(strings.NewReader(`{"responseCode": "5005500", "responseMessage": "General Error", "accessToken": "leaked"}`)),
+					}, nil
+				}
+
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{},
+					Body:       io.NopCloser(strings.NewReader(`{"responseCode": "2005500"}`)),
+				}, nil
+			})},
+		},
+	}
+
+	_, err := client.DebitStatus(context.Background(), "b2bToken", "debitExternalID", "externalID")
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	for _, secret := range []string{"debitExternalID", "leaked"} {
+		if strings.Contains(logs.String(), secret) {
+			t.Errorf("Expected %s to be redacted from %s", secret, logs.String())
+		}
+	}
+
+	for _, logged := range []string{"connection reset", `"response_code":"5005500"`} {
+		if !strings.Contains(logs.String(), logged) {
+			t.Errorf("Expected %s to be logged, got %s", logged, logs.String())
+		}
+	}
+}
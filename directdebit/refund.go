@@ -0,0 +1,79 @@
+package directdebit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// Refund reverses a successful debit, identified by OriginalPartnerReferenceNo and
+// OriginalReferenceNo. Set RefundAmount to the debited amount for a full refund
+// or to a lower amount for a partial refund.
+func (c Client) Refund(ctx context.Context, req *RefundRequest, b2bToken, b2b2cToken, externalID string) (*RefundResponse, error) {
+	b2bToken, err := c.resolveB2BToken(ctx, b2bToken)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := RefundEndpoint
+
+	if req.MerchantID == "" {
+		req.MerchantID = c.Config.MerchantID
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.send(ctx, &call{
+		operation: OperationRefund,
+		method:    http.MethodPost,
+		path:      endpoint,
+		body:      body,
+		sign:      c.hmacHeaders(http.MethodPost, endpoint, body, b2bToken, b2b2cToken, externalID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	respEntity := RefundResponse{}
+	err = json.Unmarshal(resp, &respEntity)
+	if err != nil {
+		return nil, err
+	}
+
+	return &respEntity, nil
+}
+
+// RefundStatus queries the result of the refund that was requested with refundTxExternalID.
+func (c Client) RefundStatus(
+	ctx context.Context,
+	b2bToken,
+	refundTxExternalID,
+	externalID string,
+) (*RefundResponse, error) {
+	b2bToken, err := c.resolveB2BToken(ctx, b2bToken)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := RefundStatusEndpoint + "?XExternalId=" + refundTxExternalID + "&merchantId=" + c.Config.MerchantID
+
+	resp, err := c.send(ctx, &call{
+		operation: OperationRefundStatus,
+		method:    http.MethodGet,
+		path:      endpoint,
+		sign:      c.hmacHeaders(http.MethodGet, RefundStatusEndpoint, nil, b2bToken, "", externalID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	respEntity := RefundResponse{}
+	err = json.Unmarshal(resp, &respEntity)
+	if err != nil {
+		return nil, err
+	}
+
+	return &respEntity, nil
+}
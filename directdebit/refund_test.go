@@ -0,0 +1,178 @@
+package directdebit_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit"
+)
+
+var _ = Describe("Refund", func() {
+	var (
+		client     *directdebit.Client
+		cfg        *directdebit.Config
+		server     *httptest.Server
+		request    *directdebit.RefundRequest
+		b2bToken   string
+		b2b2cToken string
+		externalID string
+	)
+
+	BeforeEach(func() {
+		cfg = &directdebit.Config{
+			ClientID:   "123",
+			MerchantID: "123",
+			HTTPClient: &http.Client{},
+		}
+
+		request = &directdebit.RefundRequest{
+			PartnerRefundNo:            "partnerRefundNo",
+			OriginalPartnerReferenceNo: "t4tn57kibeunbam9dtr89urv8h2jbem9",
+			OriginalReferenceNo:        "t4tn57kibeunbam9dtr89urv8h2jbem9",
+			RefundAmount: directdebit.Amount{
+				Value:    "500000",
+				Currency: "IDR",
+			},
+		}
+		b2bToken = "sampleB2bToken"
+		b2b2cToken = "sampleB2b2cToken"
+		externalID = "sampleExternalID"
+	})
+
+	AfterEach(func() {
+		if server != nil {
+			server.Close()
+		}
+	})
+
+	When("refund error", func() {
+		It("return error when failed to execute http", func() {
+			client, _ = directdebit.New(cfg)
+			resp, err := client.Refund(context.Background(), request, b2bToken, b2b2cToken, externalID)
+			Expect(err).To(HaveOccurred())
+			Expect(resp).To(BeNil())
+		})
+
+		It("return error when failed to unmarshal JSON", func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`invalid json`))
+			}))
+
+			cfg.EndpointBaseURL = server.URL
+			client, _ = directdebit.New(cfg)
+
+			resp, err := client.Refund(context.Background(), request, b2bToken, b2b2cToken, externalID)
+			Expect(err).To(HaveOccurred())
+			Expect(resp).To(BeNil())
+		})
+	})
+
+	When("refund successful", func() {
+		It("return correct response", func() {
+			var gotRequest directdebit.RefundRequest
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				_ = json.Unmarshal(body, &gotRequest)
+
+				respJSON, _ := json.Marshal(directdebit.RefundResponse{
+					ResponseCode:               "2005800",
+					ResponseMessage:            "success",
+					OriginalPartnerReferenceNo: gotRequest.OriginalPartnerReferenceNo,
+					OriginalReferenceNo:        gotRequest.OriginalReferenceNo,
+					PartnerRefundNo:            gotRequest.PartnerRefundNo,
+					RefundNo:                   "refundNo",
+					RefundAmount:               gotRequest.RefundAmount,
+				})
+				w.WriteHeader(http.StatusOK)
+				w.Write(respJSON)
+			}))
+
+			cfg.EndpointBaseURL = server.URL
+			cfg.MerchantID = "MEKARI"
+			client, _ = directdebit.New(cfg)
+
+			resp, err := client.Refund(context.Background(), request, b2bToken, b2b2cToken, externalID)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(gotRequest.MerchantID).Should(Equal("MEKARI"))
+			Expect(resp.RefundNo).Should(Equal("refundNo"))
+			Expect(resp.RefundAmount).Should(Equal(request.RefundAmount))
+		})
+	})
+})
+
+var _ = Describe("RefundStatus", func() {
+	var (
+		client     *directdebit.Client
+		cfg        *directdebit.Config
+		server     *httptest.Server
+		b2bToken   string
+		externalID string
+	)
+
+	BeforeEach(func() {
+		cfg = &directdebit.Config{
+			ClientID:   "123",
+			MerchantID: "123",
+			HTTPClient: &http.Client{},
+		}
+
+		b2bToken = "sampleB2bToken"
+		externalID = "sampleExternalID"
+	})
+
+	AfterEach(func() {
+		if server != nil {
+			server.Close()
+		}
+	})
+
+	When("error occurs in json.Unmarshal", func() {
+		It("returns an error", func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`invalid json`))
+			}))
+
+			cfg.EndpointBaseURL = server.URL
+			client, _ = directdebit.New(cfg)
+
+			resp, err := client.RefundStatus(context.Background(), b2bToken, "refundExternalID", externalID)
+			Expect(err).To(HaveOccurred())
+			Expect(resp).To(BeNil())
+		})
+	})
+
+	When("refund status successful", func() {
+		It("queries the refund by external id", func() {
+			var gotQuery string
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotQuery = r.URL.RawQuery
+				respJSON, _ := json.Marshal(directdebit.RefundResponse{
+					ResponseCode: "2005800",
+					AdditionalInfo: directdebit.RefundResponseAdditionalInfo{
+						RefundResult: "success",
+					},
+				})
+				w.WriteHeader(http.StatusOK)
+				w.Write(respJSON)
+			}))
+
+			cfg.EndpointBaseURL = server.URL
+			client, _ = directdebit.New(cfg)
+
+			resp, err := client.RefundStatus(context.Background(), b2bToken, "refundExternalID", externalID)
+
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(gotQuery).Should(Equal("XExternalId=refundExternalID&merchantId=123"))
+			Expect(resp.AdditionalInfo.RefundResult).Should(Equal("success"))
+		})
+	})
+})
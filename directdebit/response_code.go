@@ -0,0 +1,106 @@
+package directdebit
+
+import "strconv"
+
+// ResponseCode is a SNAP response code such as "4033318". It is made of the HTTP
+// status code (403), the service code (33) and the case code (18).
+type ResponseCode string
+
+// ServiceDebitNotification is the service of the debit notifications Ayoconnect
+// posts to NotificationHandler, which no client operation is named after.
+const ServiceDebitNotification = "DebitNotification"
+
+// services maps SNAP service codes to the operation they are returned by. Refund
+// and RefundStatus share a service code, it maps to OperationRefund.
+var services = map[string]string{
+	"01": OperationGetCardList,
+	"04": OperationVerifyOTP,
+	"07": OperationAccountBinding,
+	"09": OperationUnbind,
+	"10": OperationGetAuthCode,
+	"54": OperationDebit,
+	"55": OperationDebitStatus,
+	"56": ServiceDebitNotification,
+	"57": OperationCancelDebit,
+	"58": OperationRefund,
+	"73": OperationGetBusinessAccessToken,
+	"74": OperationGetCustomerAccessToken,
+}
+
+// Valid reports whether the code has 7 digits.
+func (c ResponseCode) Valid() bool {
+	if len(c) != 7 {
+		return false
+	}
+
+	for _, ch := range c {
+		if ch < '0' || ch > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// HTTPCode returns the HTTP status code part, or 0 when the code is not valid.
+func (c ResponseCode) HTTPCode() int {
+	if !c.Valid() {
+		return 0
+	}
+
+	code, _ := strconv.Atoi(string(c[0:3]))
+
+	return code
+}
+
+// ServiceCode returns the service code part, or an empty string when the code is not
+// valid.
+func (c ResponseCode) ServiceCode() string {
+	if !c.Valid() {
+		return ""
+	}
+
+	return string(c[3:5])
+}
+
+// CaseCode returns the case code part, or an empty string when the code is not valid.
+func (c ResponseCode) CaseCode() string {
+	if !c.Valid() {
+		return ""
+	}
+
+	return string(c[5:7])
+}
+
+// Service returns the name of the operation the service code belongs to, one of the
+// Operation constants or ServiceDebitNotification, or an empty string when the
+// service code is unknown. Responses of RefundStatus carry the service code of
+// Refund and return OperationRefund.
+func (c ResponseCode) Service() string {
+	return services[c.ServiceCode()]
+}
+
+// Description returns the SNAP description of the case code, or an empty string when
+// the case code is unknown.
+func (c ResponseCode) Description() string {
+	return c.responseCase().description
+}
+
+// IsSuccess reports whether the code is a 2xx code.
+func (c ResponseCode) IsSuccess() bool {
+	code := c.HTTPCode()
+
+	return code >= 200 && code <= 299
+}
+
+func (c ResponseCode) String() string {
+	return string(c)
+}
+
+func (c ResponseCode) responseCase() responseCase {
+	if !c.Valid() {
+		return responseCase{}
+	}
+
+	return responseCases[string(c[0:3])+string(c[5:7])]
+}
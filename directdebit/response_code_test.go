@@ -0,0 +1,69 @@
+package directdebit_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit"
+)
+
+func TestResponseCodeParts(t *testing.T) {
+	tests := []struct {
+		code        directdebit.ResponseCode
+		httpCode    int
+		serviceCode string
+		caseCode    string
+		service     string
+		description string
+	}{
+		{"2007300", 200, "73", "00", directdebit.OperationGetBusinessAccessToken, "Successful"},
+		{"4017401", 401, "74", "01", directdebit.OperationGetCustomerAccessToken, "Invalid Token (B2B)"},
+		{"4000702", 400, "07", "02", directdebit.OperationAccountBinding, "Invalid Mandatory Field"},
+		{"4035414", 403, "54", "14", directdebit.OperationDebit, "Insufficient Funds"},
+		{"4045501", 404, "55", "01", directdebit.OperationDebitStatus, "Transaction Not Found"},
+		{"4090901", 409, "09", "01", directdebit.OperationUnbind, "Duplicate partnerReferenceNo"},
+		{"2000100", 200, "01", "00", directdebit.OperationGetCardList, "Successful"},
+		{"2005600", 200, "56", "00", directdebit.ServiceDebitNotification, "Successful"},
+		{"2005800", 200, "58", "00", directdebit.OperationRefund, "Successful"},
+		{"4033318", 403, "33", "18", "", "Inactive Card/Account/Customer"},
+		{"4039999", 403, "99", "99", "", ""},
+		{"40354", 0, "", "", "", ""},
+		{"40354AB", 0, "", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.code), func(t *testing.T) {
+			if got := tt.code.HTTPCode(); got != tt.httpCode {
+				t.Errorf("HTTPCode: expected %v, got %v", tt.httpCode, got)
+			}
+			if got := tt.code.ServiceCode(); got != tt.serviceCode {
+				t.Errorf("ServiceCode: expected %q, got %q", tt.serviceCode, got)
+			}
+			if got := tt.code.CaseCode(); got != tt.caseCode {
+				t.Errorf("CaseCode: expected %q, got %q", tt.caseCode, got)
+			}
+			if got := tt.code.Service(); got != tt.service {
+				t.Errorf("Service: expected %q, got %q", tt.service, got)
+			}
+			if got := tt.code.Description(); got != tt.description {
+				t.Errorf("Description: expected %q, got %q", tt.description, got)
+			}
+		})
+	}
+}
+
+func TestResponseCodeUnmarshal(t *testing.T) {
+	var resp directdebit.DebitResponse
+	err := json.Unmarshal([]byte(`{"responseCode": "2025400", "responseMessage": "Request In Progress"}`), &resp)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !resp.ResponseCode.IsSuccess() {
+		t.Errorf("Expected %v to be a success code", resp.ResponseCode)
+	}
+
+	if resp.ResponseCode.Description() != "Request In Progress" {
+		t.Errorf("Expected %q, got %q", "Request In Progress", resp.ResponseCode.Description())
+	}
+}
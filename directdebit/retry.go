@@ -0,0 +1,262 @@
+package directdebit
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// Operation names identify the client method a request is made for.
+const (
+	OperationGetBusinessAccessToken = "GetBusinessAccessToken"
+	OperationGetCustomerAccessToken = "GetCustomerAccessToken"
+	OperationGetAuthCode            = "GetAuthCode"
+	OperationAccountBinding         = "AccountBinding"
+	OperationUnbind                 = "Unbind"
+	OperationDebit                  = "Debit"
+	OperationDebitStatus            = "DebitStatus"
+	OperationVerifyOTP              = "VerifyOTP"
+	OperationGetCardList            = "GetCardList"
+	OperationRefund                 = "Refund"
+	OperationRefundStatus           = "RefundStatus"
+	OperationCancelDebit            = "CancelDebit"
+)
+
+// RetryMode controls which failures of an operation are retried.
+type RetryMode int
+
+const (
+	// RetryNever makes a single attempt.
+	RetryNever RetryMode = iota
+	// RetryGuarded only retries failures where the request cannot have been
+	// processed: connection failures before the request was sent and throttled
+	// requests.
+	RetryGuarded
+	// RetrySafe retries every transient failure. Only use it for operations that
+	// can be repeated without side effects.
+	RetrySafe
+)
+
+// DefaultRetryModes is the retry mode of each operation unless overridden in
+// RetryPolicy.Operations. Operations that are not listed are never retried.
+var DefaultRetryModes = map[string]RetryMode{
+	OperationGetBusinessAccessToken: RetrySafe,
+	// the auth code is single-use, a request that reached Ayoconnect may have
+	// consumed it
+	OperationGetCustomerAccessToken: RetryGuarded,
+	OperationGetAuthCode:            RetrySafe,
+	OperationDebitStatus:            RetrySafe,
+	OperationGetCardList:            RetrySafe,
+	OperationRefundStatus:           RetrySafe,
+	OperationDebit:                  RetryGuarded,
+}
+
+var (
+	// RetryableHTTPStatus are the HTTP statuses retried in RetrySafe mode.
+	RetryableHTTPStatus = []int{
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	}
+
+	// RetryableResponseCode are SNAP response codes retried in RetrySafe mode
+	// regardless of the HTTP status they are returned with.
+	RetryableResponseCode = []ResponseCode{
+		"5000000", // general error
+		"5000001", // internal server error
+		"5040000", // timeout
+	}
+)
+
+// RetryPolicy configures how failed requests are retried. Every attempt is signed
+// again with a fresh X-TIMESTAMP.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts.
+	MaxBackoff time.Duration
+	// Multiplier grows the delay after every attempt.
+	Multiplier float64
+	// Jitter randomly shortens each delay by up to this fraction, between 0 and 1.
+	Jitter float64
+	// Operations overrides DefaultRetryModes per operation name.
+	Operations map[string]RetryMode
+}
+
+// DefaultRetryPolicy returns a policy making up to 3 attempts with exponential
+// backoff starting at 200ms.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+func (p *RetryPolicy) mode(operation string) RetryMode {
+	if mode, ok := p.Operations[operation]; ok {
+		return mode
+	}
+
+	return DefaultRetryModes[operation]
+}
+
+// backoff returns the delay before the given retry, counting from 1.
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	delay := float64(p.InitialBackoff) * math.Pow(multiplier, float64(retry-1))
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+
+	if p.Jitter > 0 {
+		// #nosec jitter does not need a cryptographically secure source
+		delay -= delay * math.Min(p.Jitter, 1) * rand.Float64()
+	}
+
+	return time.Duration(delay)
+}
+
+// shouldRetry reports whether err, returned by an attempt of an operation in mode,
+// may be retried.
+func shouldRetry(mode RetryMode, err error) bool {
+	if mode == RetryNever || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var respErr *ResponseError
+	if errors.As(err, &respErr) {
+		if respErr.StatusCode == http.StatusTooManyRequests {
+			return true
+		}
+
+		return mode == RetrySafe &&
+			(slices.Contains(RetryableHTTPStatus, respErr.StatusCode) ||
+				slices.Contains(RetryableResponseCode, respErr.ResponseCode))
+	}
+
+	if isConnectError(err) {
+		return true
+	}
+
+	// any other transport failure may have happened after the request was sent
+	return mode == RetrySafe
+}
+
+// isConnectError reports whether err happened before the request could be sent.
+func isConnectError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+// headerSigner returns the headers of an attempt sent at timestamp, and the string
+// that was signed for them.
+type headerSigner func(ctx context.Context, timestamp string) (RequestHeader, string, error)
+
+// call is a single logical request to Ayoconnect that may take several attempts.
+type call struct {
+	operation string
+	method    string
+	path      string
+	body      []byte
+	sign      headerSigner
+}
+
+// send executes call, retrying it according to Config.RetryPolicy.
+func (c Client) send(ctx context.Context, call *call) ([]byte, error) {
+	policy := c.Config.RetryPolicy
+	if policy == nil || policy.MaxAttempts < 1 {
+		policy = &RetryPolicy{MaxAttempts: 1}
+	}
+	mode := policy.mode(call.operation)
+	handler := c.handler()
+
+	for attempt := 1; ; attempt++ {
+		headers, stringToSign, err := call.sign(ctx, time.Now().Format(time.RFC3339))
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := handler(ctx, &Request{
+			Operation:    call.operation,
+			Method:       call.method,
+			Path:         call.path,
+			Header:       headers,
+			StringToSign: stringToSign,
+			Body:         call.body,
+			Attempt:      attempt,
+		})
+		if err == nil {
+			return resp.Body, nil
+		}
+
+		if attempt >= policy.MaxAttempts || !shouldRetry(mode, err) {
+			return nil, err
+		}
+
+		delay := policy.backoff(attempt)
+		c.observeRetry(ctx, call.operation, attempt)
+		if c.Config.Logger != nil {
+			redactor := c.redactor()
+			c.Config.Logger.WarnContext(ctx, "retrying request",
+				slog.String("operation", call.operation),
+				slog.String("path", redactor.RedactPath(call.path)),
+				slog.Int("attempt", attempt),
+				slog.Duration("delay", delay),
+				redactor.errorAttr(err),
+			)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+	}
+}
+
+// hmacHeaders signs requests to endpoints authenticated with the client secret.
+func (c Client) hmacHeaders(method, path string, body []byte, b2bToken, b2b2cToken, externalID string) headerSigner {
+	return func(_ context.Context, timestamp string) (RequestHeader, string, error) {
+		signature := generateHmacSignature(method, path, b2bToken, string(body), timestamp, c.Config.ClientSecret)
+
+		return c.BuildHeader(timestamp, signature, b2bToken, b2b2cToken, externalID),
+			hmacStringToSign(method, path, b2bToken, string(body), timestamp), nil
+	}
+}
+
+// accessTokenHeaders signs requests to the access token endpoints.
+func (c Client) accessTokenHeaders(b2bToken string) headerSigner {
+	return func(ctx context.Context, timestamp string) (RequestHeader, string, error) {
+		signature, err := c.accessTokenSignature(ctx, timestamp)
+		if err != nil {
+			return RequestHeader{}, "", err
+		}
+
+		return c.BuildHeader(timestamp, signature, b2bToken, "", ""), rsaStringToSign(c.Config.ClientID, timestamp), nil
+	}
+}
@@ -0,0 +1,192 @@
+package directdebit_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit"
+)
+
+var _ = Describe("RetryPolicy", func() {
+	var (
+		client     *directdebit.Client
+		cfg        *directdebit.Config
+		server     *httptest.Server
+		hits       atomic.Int32
+		failures   int32
+		failStatus int
+		mu         sync.Mutex
+		timestamps []string
+	)
+
+	BeforeEach(func() {
+		hits.Store(0)
+		failures = 2
+		failStatus = http.StatusServiceUnavailable
+		timestamps = nil
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			timestamps = append(timestamps, r.Header.Get("X-TIMESTAMP"))
+			mu.Unlock()
+
+			if hits.Add(1) <= failures {
+				w.WriteHeader(failStatus)
+				w.Write([]byte(`{"responseCode": "5035500", "responseMessage": "Service Unavailable"}`))
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"responseCode": "2005500", "responseMessage": "Successful"}`))
+		}))
+
+		cfg = &directdebit.Config{
+			ClientID:        "123",
+			MerchantID:      "123",
+			EndpointBaseURL: server.URL,
+			HTTPClient:      &http.Client{},
+			Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+			RetryPolicy: &directdebit.RetryPolicy{
+				MaxAttempts:    3,
+				InitialBackoff: time.Millisecond,
+				MaxBackoff:     5 * time.Millisecond,
+				Multiplier:     2,
+				Jitter:         0.5,
+			},
+		}
+		client, _ = directdebit.New(cfg)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	When("a safe operation fails with a transient error", func() {
+		It("retries until it succeeds", func() {
+			resp, err := client.DebitStatus(context.Background(), "b2bToken", "debitExternalID", "externalID")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(resp.ResponseCode).Should(BeEquivalentTo("2005500"))
+			Expect(hits.Load()).Should(BeEquivalentTo(3))
+			Expect(timestamps).Should(HaveLen(3))
+		})
+
+		It("returns the last error when all attempts fail", func() {
+			failures = 5
+
+			resp, err := client.DebitStatus(context.Background(), "b2bToken", "debitExternalID", "externalID")
+			var respErr *directdebit.ResponseError
+			Expect(errors.As(err, &respErr)).Should(BeTrue())
+			Expect(respErr.StatusCode).Should(Equal(http.StatusServiceUnavailable))
+			Expect(resp).Should(BeNil())
+			Expect(hits.Load()).Should(BeEquivalentTo(3))
+		})
+
+		It("does not retry when the policy overrides the operation", func() {
+			cfg.RetryPolicy.Operations = map[string]directdebit.RetryMode{
+				directdebit.OperationDebitStatus: directdebit.RetryNever,
+			}
+
+			_, err := client.DebitStatus(context.Background(), "b2bToken", "debitExternalID", "externalID")
+			Expect(err).Should(HaveOccurred())
+			Expect(hits.Load()).Should(BeEquivalentTo(1))
+		})
+
+		It("stops retrying when the context is done", func() {
+			cfg.RetryPolicy.InitialBackoff = time.Second
+			cfg.RetryPolicy.MaxBackoff = time.Second
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			_, err := client.DebitStatus(ctx, "b2bToken", "debitExternalID", "externalID")
+			Expect(err).Should(HaveOccurred())
+			Expect(hits.Load()).Should(BeEquivalentTo(1))
+		})
+	})
+
+	When("a safe operation fails with a client error", func() {
+		It("does not retry", func() {
+			failStatus = http.StatusBadRequest
+
+			_, err := client.DebitStatus(context.Background(), "b2bToken", "debitExternalID", "externalID")
+			Expect(err).Should(HaveOccurred())
+			Expect(hits.Load()).Should(BeEquivalentTo(1))
+		})
+	})
+
+	When("a debit fails after it may have been processed", func() {
+		It("does not retry", func() {
+			_, err := client.Debit(context.Background(), &directdebit.DebitRequest{}, "b2bToken", "b2b2cToken", "externalID")
+			Expect(err).Should(HaveOccurred())
+			Expect(hits.Load()).Should(BeEquivalentTo(1))
+		})
+	})
+
+	When("a customer access token request fails after it may have been processed", func() {
+		It("does not retry, as the auth code may have been used", func() {
+			cfg.Signer = signerFunc(func(_ context.Context, _ []byte) ([]byte, error) {
+				return []byte("signature"), nil
+			})
+			client, _ = directdebit.New(cfg)
+
+			_, err := client.GetCustomerAccessToken(context.Background(), "authCode", "b2bToken")
+			Expect(err).Should(HaveOccurred())
+			Expect(hits.Load()).Should(BeEquivalentTo(1))
+		})
+	})
+
+	When("a debit is throttled", func() {
+		It("retries", func() {
+			failStatus = http.StatusTooManyRequests
+
+			_, err := client.Debit(context.Background(), &directdebit.DebitRequest{}, "b2bToken", "b2b2cToken", "externalID")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(hits.Load()).Should(BeEquivalentTo(3))
+		})
+	})
+
+	When("a debit cannot connect", func() {
+		It("retries", func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).ShouldNot(HaveOccurred())
+			cfg.EndpointBaseURL = "http://" + listener.Addr().String()
+			Expect(listener.Close()).Should(Succeed())
+
+			var attempts atomic.Int32
+			cfg.HTTPClient = &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				attempts.Add(1)
+				return http.DefaultTransport.RoundTrip(r)
+			})}
+
+			_, err = client.Debit(context.Background(), &directdebit.DebitRequest{}, "b2bToken", "b2b2cToken", "externalID")
+			Expect(err).Should(HaveOccurred())
+			Expect(attempts.Load()).Should(BeEquivalentTo(3))
+		})
+	})
+
+	When("no policy is configured", func() {
+		It("makes a single attempt", func() {
+			cfg.RetryPolicy = nil
+
+			_, err := client.DebitStatus(context.Background(), "b2bToken", "debitExternalID", "externalID")
+			Expect(err).Should(HaveOccurred())
+			Expect(hits.Load()).Should(BeEquivalentTo(1))
+		})
+	})
+})
+
+type roundTripperFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
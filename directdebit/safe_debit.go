@@ -0,0 +1,179 @@
+package directdebit
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// ErrDebitUnresolved is returned by SafeDebit when the outcome of a debit is still
+// unknown after reconciliation. The customer may or may not have been charged, so
+// the debit must not be sent again with a new PartnerReferenceNo until DebitStatus
+// or a notification settles it.
+var ErrDebitUnresolved = errors.New("debit outcome is unknown")
+
+// FinalTransactionStatus are the statuses after which a debit no longer changes
+// until it is refunded.
+var FinalTransactionStatus = []string{
+	TransactionStatusSuccess,
+	TransactionStatusRefunded,
+	TransactionStatusCanceled,
+	TransactionStatusFailed,
+}
+
+// ReconcilePolicy controls how SafeDebit polls DebitStatus.
+type ReconcilePolicy struct {
+	// MaxAttempts is the number of DebitStatus queries made before giving up.
+	MaxAttempts int
+	// Interval is the delay before each query.
+	Interval time.Duration
+	// NewExternalID returns the X-EXTERNAL-ID of a DebitStatus query. Defaults to a
+	// random 32 digit number.
+	NewExternalID func() string
+	// Timeout bounds the whole reconciliation. Defaults to MaxAttempts times
+	// Interval plus ReconcileQueryTimeout.
+	Timeout time.Duration
+}
+
+// ReconcileQueryTimeout is the time allowed for each DebitStatus query when
+// ReconcilePolicy.Timeout is not set.
+const ReconcileQueryTimeout = 10 * time.Second
+
+func (p *ReconcilePolicy) timeout() time.Duration {
+	if p.Timeout > 0 {
+		return p.Timeout
+	}
+
+	return time.Duration(p.MaxAttempts) * (p.Interval + ReconcileQueryTimeout)
+}
+
+// DefaultReconcilePolicy returns a policy querying DebitStatus up to 5 times, 2
+// seconds apart.
+func DefaultReconcilePolicy() *ReconcilePolicy {
+	return &ReconcilePolicy{
+		MaxAttempts: 5,
+		Interval:    2 * time.Second,
+	}
+}
+
+// SafeDebit calls Debit and, when it fails in a way that leaves the outcome unknown
+// such as a timeout or a dropped connection after the request was sent, queries
+// DebitStatus with externalID until the debit reaches one of the
+// FinalTransactionStatus. The status response is returned in place of the error;
+// check its LatestTransactionStatus. When no final status is known once
+// Config.ReconcilePolicy is exhausted, including when the debit is still not found,
+// the returned error wraps ErrDebitUnresolved and the original error.
+//
+// The status is queried even when ctx is done, as the debit most likely timed out
+// because of it. The queries keep the values of ctx but are only bounded by
+// ReconcilePolicy.Timeout.
+//
+// externalID and req.PartnerReferenceNo are required, as they are what the debit is
+// reconciled by.
+func (c Client) SafeDebit(ctx context.Context, req *DebitRequest, b2bToken, b2b2cToken, externalID string) (*DebitResponse, error) {
+	if externalID == "" {
+		return nil, fmt.Errorf("%w: SafeDebit requires an external ID", ErrValidation)
+	}
+
+	if req.PartnerReferenceNo == "" {
+		return nil, fmt.Errorf("%w: SafeDebit requires a partner reference number", ErrValidation)
+	}
+
+	resp, err := c.Debit(ctx, req, b2bToken, b2b2cToken, externalID)
+	if err == nil || !isAmbiguousDebitError(err) {
+		return resp, err
+	}
+
+	policy := c.Config.ReconcilePolicy
+	if policy == nil {
+		policy = DefaultReconcilePolicy()
+	}
+
+	newExternalID := policy.NewExternalID
+	if newExternalID == nil {
+		newExternalID = randomExternalID
+	}
+
+	if c.Config.Logger != nil {
+		c.Config.Logger.WarnContext(ctx, "reconciling ambiguous debit",
+			slog.String("partner_reference_no", req.PartnerReferenceNo),
+			slog.String("external_id", externalID),
+			c.redactor().errorAttr(err),
+		)
+	}
+
+	ctx, cancel := context.WithTimeout(detachedContext{ctx}, policy.timeout())
+	defer cancel()
+
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+		timer := time.NewTimer(policy.Interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("%w: %w", ErrDebitUnresolved, err)
+		case <-timer.C:
+		}
+
+		status, statusErr := c.DebitStatus(ctx, b2bToken, externalID, newExternalID())
+		if statusErr != nil {
+			var respErr *ResponseError
+			if errors.As(statusErr, &respErr) && respErr.StatusCode < 500 && !isTransactionNotFound(respErr.ResponseCode) {
+				return nil, fmt.Errorf("%w: %w", ErrDebitUnresolved, statusErr)
+			}
+			continue
+		}
+
+		if slices.Contains(FinalTransactionStatus, status.LatestTransactionStatus) {
+			return status, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %w", ErrDebitUnresolved, err)
+}
+
+// isTransactionNotFound reports whether code is the Transaction Not Found answer of
+// DebitStatus. The debit may still show up, so SafeDebit keeps querying.
+func isTransactionNotFound(code ResponseCode) bool {
+	return code.Service() == OperationDebitStatus && code.CaseCode() == "01"
+}
+
+// detachedContext keeps the values of a context but not its deadline or
+// cancellation.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+// isAmbiguousDebitError reports whether a debit that failed with err may still have
+// been processed by Ayoconnect.
+func isAmbiguousDebitError(err error) bool {
+	if isConnectError(err) {
+		return false
+	}
+
+	var respErr *ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.StatusCode >= 500 || slices.Contains(RetryableResponseCode, respErr.ResponseCode)
+	}
+
+	return true
+}
+
+func randomExternalID() string {
+	n, err := rand.Int(rand.Reader, new(big.Int).Exp(big.NewInt(10), big.NewInt(32), nil))
+	if err != nil {
+		// crypto/rand does not fail on supported platforms
+		panic(err)
+	}
+
+	return fmt.Sprintf("%032s", n.String())
+}
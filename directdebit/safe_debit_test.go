@@ -0,0 +1,243 @@
+package directdebit_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit"
+)
+
+var _ = Describe("SafeDebit", func() {
+	var (
+		client         *directdebit.Client
+		cfg            *directdebit.Config
+		server         *httptest.Server
+		request        *directdebit.DebitRequest
+		mu             sync.Mutex
+		debitHandler   http.HandlerFunc
+		statusBodies   []string
+		statusQueries  []string
+		statusExtIDs   []string
+		statusStatus   int
+		externalIDSeed int
+	)
+
+	BeforeEach(func() {
+		statusBodies = nil
+		statusQueries = nil
+		statusExtIDs = nil
+		statusStatus = http.StatusOK
+		externalIDSeed = 0
+
+		debitHandler = func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"responseCode": "2025400", "responseMessage": "Successful", "partnerReferenceNo": "ref"}`))
+		}
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case directdebit.DebitEndpoint:
+				debitHandler(w, r)
+			case directdebit.DebitStatusEndpoint:
+				mu.Lock()
+				defer mu.Unlock()
+				statusQueries = append(statusQueries, r.URL.Query().Get("XExternalId"))
+				statusExtIDs = append(statusExtIDs, r.Header.Get("X-EXTERNAL-ID"))
+				body := `{"responseCode": "4045501", "responseMessage": "Transaction Not Found"}`
+				if len(statusBodies) > 0 {
+					body, statusBodies = statusBodies[0], statusBodies[1:]
+				}
+				w.WriteHeader(statusStatus)
+				w.Write([]byte(body))
+			}
+		}))
+
+		cfg = &directdebit.Config{
+			ClientID:        "123",
+			MerchantID:      "123",
+			EndpointBaseURL: server.URL,
+			HTTPClient:      &http.Client{Timeout: 50 * time.Millisecond},
+			Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+			ReconcilePolicy: &directdebit.ReconcilePolicy{
+				MaxAttempts: 3,
+				Interval:    time.Millisecond,
+				NewExternalID: func() string {
+					externalIDSeed++
+					return "status" + strconv.Itoa(externalIDSeed)
+				},
+			},
+		}
+		client, _ = directdebit.New(cfg)
+
+		request = &directdebit.DebitRequest{PartnerReferenceNo: "ref", BankCardToken: "card"}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	When("the debit succeeds", func() {
+		It("returns the debit response without querying the status", func() {
+			resp, err := client.SafeDebit(context.Background(), request, "b2bToken", "b2b2cToken", "debitExternalID")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(resp.ResponseCode).Should(BeEquivalentTo("2025400"))
+			Expect(statusQueries).Should(BeEmpty())
+		})
+	})
+
+	When("the debit is rejected", func() {
+		It("returns the response error without querying the status", func() {
+			debitHandler = func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"responseCode": "4035414", "responseMessage": "Insufficient Funds"}`))
+			}
+
+			resp, err := client.SafeDebit(context.Background(), request, "b2bToken", "b2b2cToken", "debitExternalID")
+			var respErr *directdebit.ResponseError
+			Expect(errors.As(err, &respErr)).Should(BeTrue())
+			Expect(errors.Is(err, directdebit.ErrDebitUnresolved)).Should(BeFalse())
+			Expect(resp).Should(BeNil())
+			Expect(statusQueries).Should(BeEmpty())
+		})
+	})
+
+	When("the debit cannot be reconciled", func() {
+		It("requires an external ID and a partner reference number", func() {
+			_, err := client.SafeDebit(context.Background(), request, "b2bToken", "b2b2cToken", "")
+			Expect(err).Should(MatchError(directdebit.ErrValidation))
+
+			request.PartnerReferenceNo = ""
+			_, err = client.SafeDebit(context.Background(), request, "b2bToken", "b2b2cToken", "debitExternalID")
+			Expect(err).Should(MatchError(directdebit.ErrValidation))
+		})
+	})
+
+	When("the debit times out", func() {
+		BeforeEach(func() {
+			debitHandler = func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(100 * time.Millisecond)
+				w.WriteHeader(http.StatusOK)
+			}
+		})
+
+		It("returns the final status of the debit", func() {
+			statusBodies = []string{
+				`{"responseCode": "2005500", "latestTransactionStatus": "03"}`,
+				`{"responseCode": "2005500", "latestTransactionStatus": "00", "referenceNo": "ayoRef"}`,
+			}
+
+			resp, err := client.SafeDebit(context.Background(), request, "b2bToken", "b2b2cToken", "debitExternalID")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(resp.LatestTransactionStatus).Should(Equal(directdebit.TransactionStatusSuccess))
+			Expect(resp.ReferenceNo).Should(Equal("ayoRef"))
+			Expect(statusQueries).Should(Equal([]string{"debitExternalID", "debitExternalID"}))
+			Expect(statusExtIDs).Should(Equal([]string{"status1", "status2"}))
+		})
+
+		It("returns ErrDebitUnresolved when the debit is still not found", func() {
+			statusStatus = http.StatusNotFound
+
+			resp, err := client.SafeDebit(context.Background(), request, "b2bToken", "b2b2cToken", "debitExternalID")
+			Expect(err).Should(MatchError(directdebit.ErrDebitUnresolved))
+			Expect(resp).Should(BeNil())
+			Expect(statusQueries).Should(HaveLen(3))
+		})
+
+		It("returns ErrDebitUnresolved when the transaction status is still not found", func() {
+			statusBodies = []string{
+				`{"responseCode": "2005500", "latestTransactionStatus": "07"}`,
+				`{"responseCode": "2005500", "latestTransactionStatus": "07"}`,
+				`{"responseCode": "2005500", "latestTransactionStatus": "07"}`,
+			}
+
+			resp, err := client.SafeDebit(context.Background(), request, "b2bToken", "b2b2cToken", "debitExternalID")
+			Expect(err).Should(MatchError(directdebit.ErrDebitUnresolved))
+			Expect(resp).Should(BeNil())
+			Expect(statusQueries).Should(HaveLen(3))
+		})
+
+		It("stops on a not found that is not Transaction Not Found", func() {
+			statusStatus = http.StatusNotFound
+			statusBodies = []string{`{"responseCode": "4045500", "responseMessage": "Invalid Transaction Status"}`}
+
+			_, err := client.SafeDebit(context.Background(), request, "b2bToken", "b2b2cToken", "debitExternalID")
+			Expect(err).Should(MatchError(directdebit.ErrDebitUnresolved))
+			Expect(statusQueries).Should(HaveLen(1))
+
+			statusQueries = nil
+			statusBodies = []string{`<html>Not Found</html>`}
+
+			_, err = client.SafeDebit(context.Background(), request, "b2bToken", "b2b2cToken", "debitExternalID")
+			Expect(err).Should(MatchError(directdebit.ErrDebitUnresolved))
+			Expect(statusQueries).Should(HaveLen(1))
+		})
+
+		It("returns ErrDebitUnresolved when the debit is found after not being found", func() {
+			statusBodies = []string{
+				`{"responseCode": "2005500", "latestTransactionStatus": "07"}`,
+				`{"responseCode": "2005500", "latestTransactionStatus": "07"}`,
+				`{"responseCode": "2005500", "latestTransactionStatus": "03"}`,
+			}
+
+			_, err := client.SafeDebit(context.Background(), request, "b2bToken", "b2b2cToken", "debitExternalID")
+			Expect(err).Should(MatchError(directdebit.ErrDebitUnresolved))
+		})
+
+		It("returns ErrDebitUnresolved when the status never becomes final", func() {
+			statusBodies = []string{
+				`{"responseCode": "2005500", "latestTransactionStatus": "03"}`,
+				`{"responseCode": "2005500", "latestTransactionStatus": "03"}`,
+				`{"responseCode": "2005500", "latestTransactionStatus": "03"}`,
+			}
+
+			resp, err := client.SafeDebit(context.Background(), request, "b2bToken", "b2b2cToken", "debitExternalID")
+			Expect(err).Should(MatchError(directdebit.ErrDebitUnresolved))
+			Expect(resp).Should(BeNil())
+			Expect(statusQueries).Should(HaveLen(3))
+		})
+
+		It("queries the status after the deadline of ctx", func() {
+			cfg.HTTPClient = &http.Client{}
+			statusBodies = []string{`{"responseCode": "2005500", "latestTransactionStatus": "00"}`}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			resp, err := client.SafeDebit(ctx, request, "b2bToken", "b2b2cToken", "debitExternalID")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(resp.LatestTransactionStatus).Should(Equal(directdebit.TransactionStatusSuccess))
+			Expect(statusQueries).Should(HaveLen(1))
+		})
+
+		It("gives up once ReconcilePolicy.Timeout has passed", func() {
+			cfg.ReconcilePolicy.Interval = 20 * time.Millisecond
+			cfg.ReconcilePolicy.Timeout = 30 * time.Millisecond
+
+			_, err := client.SafeDebit(context.Background(), request, "b2bToken", "b2b2cToken", "debitExternalID")
+			Expect(err).Should(MatchError(directdebit.ErrDebitUnresolved))
+			Expect(statusQueries).Should(HaveLen(1))
+		})
+
+		It("stops when the status query is rejected", func() {
+			statusStatus = http.StatusUnauthorized
+			statusBodies = []string{`{"responseCode": "4015500", "responseMessage": "Unauthorized"}`}
+
+			_, err := client.SafeDebit(context.Background(), request, "b2bToken", "b2b2cToken", "debitExternalID")
+			Expect(err).Should(MatchError(directdebit.ErrDebitUnresolved))
+			var respErr *directdebit.ResponseError
+			Expect(errors.As(err, &respErr)).Should(BeTrue())
+			Expect(respErr.ResponseCode).Should(BeEquivalentTo("4015500"))
+			Expect(statusQueries).Should(HaveLen(1))
+		})
+	})
+})
@@ -1,42 +1,59 @@
 package directdebit
 
 import (
+	"context"
 	"crypto"
 	"crypto/hmac"
-	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/sha512"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"time"
 )
 
+// DefaultMaxClockSkew is the largest difference allowed between a signature
+// timestamp and the local clock when no other value is configured.
+const DefaultMaxClockSkew = 5 * time.Minute
+
 var (
-	ErrParsePEMBlock      = errors.New("failed to parse PEM block containing the private key")
-	GenerateRSASignature  = generateRSASignature
-	GenerateHmacSignature = generateHmacSignature
+	ErrInvalidSignature         = errors.New("invalid signature")
+	ErrInvalidTimestamp         = errors.New("invalid or expired timestamp")
+	ErrParsePEMBlock            = errors.New("failed to parse PEM block containing the private key")
+	ErrParsePublicKeyPEMBlock   = errors.New("failed to parse PEM block containing the public key")
+	ErrNotRSAPublicKey          = errors.New("public key is not an RSA public key")
+	ErrNotRSAPrivateKey         = errors.New("private key is not an RSA private key")
+	GenerateRSASignature        = generateRSASignature
+	GenerateRSARequestSignature = generateRSARequestSignature
+	GenerateHmacSignature       = generateHmacSignature
 )
 
 func generateRSASignature(timestamp string, privkey string, clientID string) (string, error) {
-	privKeyBlock, _ := pem.Decode([]byte(privkey))
-	if privKeyBlock == nil {
-		return "", ErrParsePEMBlock
+	signer, err := NewPEMSigner(privkey, "")
+	if err != nil {
+		return "", err
 	}
 
-	privateKey, err := x509.ParsePKCS8PrivateKey(privKeyBlock.Bytes)
+	signature, err := signer.Sign(context.Background(), []byte(rsaStringToSign(clientID, timestamp)))
 	if err != nil {
 		return "", err
 	}
 
-	pkey, ok := privateKey.(*rsa.PrivateKey)
-	if !ok {
+	return fmt.Sprintf("%x", signature), nil
+}
+
+// generateRSARequestSignature signs a request with privkey over its method, path,
+// body and timestamp, as Ayoconnect signs the notifications it sends.
+func generateRSARequestSignature(httpMethod string, path string, jsonString string, timestamp string, privkey string) (string, error) {
+	signer, err := NewPEMSigner(privkey, "")
+	if err != nil {
 		return "", err
 	}
 
-	hash := sha256.Sum256([]byte(clientID + "|" + timestamp))
-	signature, err := rsa.SignPKCS1v15(rand.Reader, pkey, crypto.SHA256, hash[:])
+	signature, err := signer.Sign(context.Background(), []byte(rsaRequestStringToSign(httpMethod, path, jsonString, timestamp)))
 	if err != nil {
 		return "", err
 	}
@@ -45,9 +62,155 @@ func generateRSASignature(timestamp string, privkey string, clientID string) (st
 }
 
 func generateHmacSignature(httpMethod string, path string, accessToken string, jsonString string, timestamp string, clientSecret string) string {
-	stringToSign := httpMethod + ":" + path + ":" + accessToken + ":" + fmt.Sprintf("%x", sha256.Sum256([]byte(jsonString))) + ":" + timestamp
+	stringToSign := hmacStringToSign(httpMethod, path, accessToken, jsonString, timestamp)
 	hmac := hmac.New(sha512.New, []byte(clientSecret))
 
 	hmac.Write([]byte(stringToSign))
 	return fmt.Sprintf("%x", hmac.Sum(nil))
 }
+
+// hmacStringToSign returns the string signed by generateHmacSignature.
+func hmacStringToSign(httpMethod string, path string, accessToken string, jsonString string, timestamp string) string {
+	return httpMethod + ":" + path + ":" + accessToken + ":" + bodyHash([]byte(jsonString)) + ":" + timestamp
+}
+
+// rsaStringToSign returns the string signed for access token requests.
+func rsaStringToSign(clientID string, timestamp string) string {
+	return clientID + "|" + timestamp
+}
+
+// rsaRequestStringToSign returns the string signed by generateRSARequestSignature.
+func rsaRequestStringToSign(httpMethod string, path string, jsonString string, timestamp string) string {
+	return httpMethod + ":" + path + ":" + bodyHash([]byte(jsonString)) + ":" + timestamp
+}
+
+// bodyHash returns the lowercase hex SHA-256 of a request body, as used in the string
+// to sign.
+func bodyHash(body []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(body))
+}
+
+// VerifyHmacSignature checks a signature produced with the same scheme as
+// GenerateHmacSignature, and that timestamp is within maxSkew of the local clock.
+// A maxSkew of zero or less uses DefaultMaxClockSkew. It returns ErrInvalidTimestamp
+// or ErrInvalidSignature when verification fails.
+func VerifyHmacSignature(
+	signature string,
+	httpMethod string,
+	path string,
+	accessToken string,
+	jsonString string,
+	timestamp string,
+	clientSecret string,
+	maxSkew time.Duration,
+) error {
+	err := verifyTimestamp(timestamp, maxSkew, time.Now())
+	if err != nil {
+		return err
+	}
+
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	expected, _ := hex.DecodeString(generateHmacSignature(httpMethod, path, accessToken, jsonString, timestamp, clientSecret))
+	if !hmac.Equal(got, expected) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// VerifyRSASignature checks a signature produced with the same scheme as
+// GenerateRSASignature against pubkey, a PEM encoded PKIX or PKCS#1 RSA public key
+// such as the one Ayoconnect provides, and that timestamp is within maxSkew of the
+// local clock. A maxSkew of zero or less uses DefaultMaxClockSkew.
+//
+// The signature only covers clientID and timestamp, use VerifyRSARequestSignature to
+// authenticate a request such as a notification.
+func VerifyRSASignature(signature string, timestamp string, pubkey string, clientID string, maxSkew time.Duration) error {
+	return verifyRSASignature(signature, rsaStringToSign(clientID, timestamp), timestamp, pubkey, maxSkew)
+}
+
+// VerifyRSARequestSignature checks a signature produced with the same scheme as
+// GenerateRSARequestSignature, over the method, path, SHA-256 of the body and
+// timestamp of a request, against pubkey. It authenticates notifications signed with
+// the private key of Ayoconnect, a signature cannot be replayed with another body.
+// A maxSkew of zero or less uses DefaultMaxClockSkew.
+func VerifyRSARequestSignature(
+	signature string,
+	httpMethod string,
+	path string,
+	jsonString string,
+	timestamp string,
+	pubkey string,
+	maxSkew time.Duration,
+) error {
+	return verifyRSASignature(signature, rsaRequestStringToSign(httpMethod, path, jsonString, timestamp), timestamp, pubkey, maxSkew)
+}
+
+func verifyRSASignature(signature string, stringToSign string, timestamp string, pubkey string, maxSkew time.Duration) error {
+	publicKey, err := parseRSAPublicKey(pubkey)
+	if err != nil {
+		return err
+	}
+
+	err = verifyTimestamp(timestamp, maxSkew, time.Now())
+	if err != nil {
+		return err
+	}
+
+	sig, err := hex.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	hash := sha256.Sum256([]byte(stringToSign))
+	if rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hash[:], sig) != nil {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func parseRSAPublicKey(pubkey string) (*rsa.PublicKey, error) {
+	pubKeyBlock, _ := pem.Decode([]byte(pubkey))
+	if pubKeyBlock == nil {
+		return nil, ErrParsePublicKeyPEMBlock
+	}
+
+	if pubKeyBlock.Type == "RSA PUBLIC KEY" {
+		return x509.ParsePKCS1PublicKey(pubKeyBlock.Bytes)
+	}
+
+	publicKey, err := x509.ParsePKIXPublicKey(pubKeyBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	pkey, ok := publicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, ErrNotRSAPublicKey
+	}
+
+	return pkey, nil
+}
+
+func verifyTimestamp(timestamp string, maxSkew time.Duration, now time.Time) error {
+	signedAt, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+
+	if maxSkew <= 0 {
+		maxSkew = DefaultMaxClockSkew
+	}
+
+	diff := now.Sub(signedAt)
+	if diff > maxSkew || diff < -maxSkew {
+		return ErrInvalidTimestamp
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package directdebit
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+)
+
+// Signer signs the X-SIGNATURE of access token requests. Sign hashes message with
+// SHA-256 and returns its RSASSA-PKCS1-v1_5 signature.
+type Signer interface {
+	Sign(ctx context.Context, message []byte) ([]byte, error)
+}
+
+type cryptoSigner struct {
+	signer crypto.Signer
+}
+
+// NewCryptoSigner returns a Signer backed by any crypto.Signer holding an RSA key,
+// such as an *rsa.PrivateKey or a key that lives in a KMS or HSM.
+func NewCryptoSigner(signer crypto.Signer) Signer {
+	return cryptoSigner{signer: signer}
+}
+
+func (s cryptoSigner) Sign(_ context.Context, message []byte) ([]byte, error) {
+	hash := sha256.Sum256(message)
+
+	return s.signer.Sign(rand.Reader, hash[:], crypto.SHA256)
+}
+
+// NewPEMSigner parses a PEM encoded RSA private key and returns a Signer for it.
+// PKCS#1 and PKCS#8 keys are accepted. Encrypted keys are decrypted with
+// passphrase, which is ignored for unencrypted keys.
+func NewPEMSigner(privkey string, passphrase string) (Signer, error) {
+	pkey, err := parseRSAPrivateKey(privkey, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCryptoSigner(pkey), nil
+}
+
+// failedSigner reports the error that prevented the configured key from being loaded.
+type failedSigner struct {
+	err error
+}
+
+func (s failedSigner) Sign(_ context.Context, _ []byte) ([]byte, error) {
+	return nil, s.err
+}
+
+// newConfigSigner returns Config.Signer, or a Signer for Config.RsaPrivateKey.
+func newConfigSigner(c *Config) (Signer, error) {
+	if c.Signer != nil {
+		return c.Signer, nil
+	}
+
+	if c.RsaPrivateKey == "" {
+		// clients that only call HMAC signed endpoints do not need a private key
+		return failedSigner{err: ErrMissingPrivateKey}, nil
+	}
+
+	return NewPEMSigner(c.RsaPrivateKey, c.RsaPrivateKeyPassphrase)
+}
+
+// accessTokenSignature returns the X-SIGNATURE for access token requests sent at timestamp.
+func (c Client) accessTokenSignature(ctx context.Context, timestamp string) (string, error) {
+	signer := c.signer
+	if signer == nil {
+		var err error
+		signer, err = newConfigSigner(c.Config)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	signature, err := signer.Sign(ctx, []byte(rsaStringToSign(c.Config.ClientID, timestamp)))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", signature), nil
+}
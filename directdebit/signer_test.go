@@ -0,0 +1,117 @@
+package directdebit_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/praswicaksono/ayoconnect-direct-debit-go/directdebit"
+)
+
+type signerFunc func(ctx context.Context, message []byte) ([]byte, error)
+
+func (f signerFunc) Sign(ctx context.Context, message []byte) ([]byte, error) {
+	return f(ctx, message)
+}
+
+var _ = Describe("Signer", func() {
+	var (
+		client       *directdebit.Client
+		cfg          *directdebit.Config
+		server       *httptest.Server
+		gotSignature string
+		gotTimestamp string
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotSignature = r.Header.Get("X-SIGNATURE")
+			gotTimestamp = r.Header.Get("X-TIMESTAMP")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"accessToken": "token", "expiresIn": 3599}`))
+		}))
+
+		cfg = &directdebit.Config{
+			ClientID:        "123",
+			MerchantID:      "123",
+			EndpointBaseURL: server.URL,
+			HTTPClient:      &http.Client{},
+			Logger:          slog.Default(),
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	When("a crypto.Signer is configured", func() {
+		It("signs access token requests with it", func() {
+			block, _ := pem.Decode([]byte(verifyPrivateKey))
+			key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			cfg.Signer = directdebit.NewCryptoSigner(key.(crypto.Signer))
+			client, _ = directdebit.New(cfg)
+
+			_, err = client.GetBusinessAccessToken(context.Background())
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(directdebit.VerifyRSASignature(gotSignature, gotTimestamp, verifyPublicKey, "123", 0)).Should(Succeed())
+		})
+	})
+
+	When("a custom Signer is configured", func() {
+		It("takes precedence over RsaPrivateKey", func() {
+			var gotMessage string
+			cfg.RsaPrivateKey = "InvalidPrivateKey"
+			cfg.Signer = signerFunc(func(_ context.Context, message []byte) ([]byte, error) {
+				gotMessage = string(message)
+				return []byte{0xca, 0xfe}, nil
+			})
+			client, _ = directdebit.New(cfg)
+
+			_, err := client.GetBusinessAccessToken(context.Background())
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(gotMessage).Should(Equal("123|" + gotTimestamp))
+			Expect(gotSignature).Should(Equal("cafe"))
+		})
+
+		It("returns the signer error", func() {
+			signErr := errors.New("kms unavailable")
+			cfg.Signer = signerFunc(func(_ context.Context, _ []byte) ([]byte, error) {
+				return nil, signErr
+			})
+			client, _ = directdebit.New(cfg)
+
+			resp, err := client.GetBusinessAccessToken(context.Background())
+			Expect(err).Should(MatchError(signErr))
+			Expect(resp).Should(BeNil())
+		})
+	})
+
+	When("a PEM private key is configured", func() {
+		It("signs access token requests with it", func() {
+			cfg.RsaPrivateKey = verifyPrivateKey
+			client, _ = directdebit.New(cfg)
+
+			_, err := client.GetBusinessAccessToken(context.Background())
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(directdebit.VerifyRSASignature(gotSignature, gotTimestamp, verifyPublicKey, "123", 0)).Should(Succeed())
+		})
+	})
+})
+
+var _ = Describe("NewPEMSigner", func() {
+	It("returns an error for an invalid PEM block", func() {
+		signer, err := directdebit.NewPEMSigner("InvalidPrivateKey", "")
+		Expect(err).Should(MatchError(directdebit.ErrParsePEMBlock))
+		Expect(signer).Should(BeNil())
+	})
+})
@@ -0,0 +1,223 @@
+package directdebit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// DefaultTokenRefreshLeeway is how long before expiry a cached B2B token is refreshed
+// when Config.TokenRefreshLeeway is not set.
+const DefaultTokenRefreshLeeway = time.Minute
+
+// TokenRefreshTimeout bounds a shared token request. The request does not use the
+// context of the caller that started it, so that callers giving up do not fail the
+// others waiting for the same token.
+const TokenRefreshTimeout = 30 * time.Second
+
+// DefaultTokenLifetime is how long a B2B token is cached when its response carries
+// no expiresIn. A token that expires earlier is dropped once Ayoconnect rejects it.
+const DefaultTokenLifetime = 15 * time.Minute
+
+var ErrEmptyAccessToken = errors.New("access token response does not contain an access token")
+
+// rejectedTokenCaseCodes are the case codes of the HTTP 401 responses rejecting a B2B
+// access token as invalid or unknown.
+var rejectedTokenCaseCodes = []string{"01", "03"}
+
+type tokenFetcher func(ctx context.Context) (*GetAccessTokenResponse, error)
+
+type tokenCall struct {
+	done chan struct{}
+	// generation is the TokenManager generation the call was started in.
+	generation int
+	token      string
+	err        error
+}
+
+// TokenManager caches the B2B access token returned by GetBusinessAccessToken and
+// refreshes it ahead of expiry. Concurrent callers that need a new token share a
+// single upstream request.
+type TokenManager struct {
+	fetch  tokenFetcher
+	leeway time.Duration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+	// refreshAt is when the token is refreshed ahead of expiresAt, at most half of
+	// its lifetime early.
+	refreshAt time.Time
+	inflight  *tokenCall
+	// generation is incremented by Invalidate, a refresh started in an older
+	// generation is not cached.
+	generation int
+}
+
+func newTokenManager(fetch tokenFetcher, leeway time.Duration) *TokenManager {
+	if leeway <= 0 {
+		leeway = DefaultTokenRefreshLeeway
+	}
+
+	return &TokenManager{
+		fetch:  fetch,
+		leeway: leeway,
+		now:    time.Now,
+	}
+}
+
+// Token returns the cached B2B access token, requesting a new one when none is cached
+// or the cached one is about to expire. When a proactive refresh fails while the
+// cached token is still valid, the cached token is returned instead of the error.
+func (m *TokenManager) Token(ctx context.Context) (string, error) {
+	m.mu.Lock()
+	now := m.now()
+	if m.token != "" && now.Before(m.refreshAt) {
+		token := m.token
+		m.mu.Unlock()
+		return token, nil
+	}
+
+	stale, staleExpiresAt := m.token, m.expiresAt
+	call := m.inflight
+	if call == nil {
+		call = &tokenCall{done: make(chan struct{}), generation: m.generation}
+		m.inflight = call
+		go m.refresh(call)
+	}
+	m.mu.Unlock()
+
+	select {
+	case <-call.done:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+
+	if call.err != nil && stale != "" && m.now().Before(staleExpiresAt) {
+		return stale, nil
+	}
+
+	return call.token, call.err
+}
+
+// Invalidate drops the cached token so that the next call to Token requests a new one.
+// It is useful when the gateway rejects a token before its advertised expiry. A
+// refresh in flight still answers the callers already waiting for it, but its token
+// is not cached.
+func (m *TokenManager) Invalidate() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.invalidate()
+}
+
+// reject invalidates the cached token when it is token, a token supplied by the
+// caller does not affect it.
+func (m *TokenManager) reject(token string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if token != "" && token == m.token {
+		m.invalidate()
+	}
+}
+
+// invalidate must be called with mu held.
+func (m *TokenManager) invalidate() {
+	m.token = ""
+	m.expiresAt = time.Time{}
+	m.refreshAt = time.Time{}
+	m.inflight = nil
+	m.generation++
+}
+
+func (m *TokenManager) refresh(call *tokenCall) {
+	ctx, cancel := context.WithTimeout(context.Background(), TokenRefreshTimeout)
+	defer cancel()
+
+	requestedAt := m.now()
+	resp, err := m.fetch(ctx)
+	if err == nil && resp.AccessToken == "" {
+		err = ErrEmptyAccessToken
+	}
+
+	m.mu.Lock()
+	if err == nil {
+		call.token = resp.AccessToken
+		if call.generation == m.generation {
+			lifetime := time.Duration(resp.ExpiredIn) * time.Second
+			if lifetime <= 0 {
+				lifetime = DefaultTokenLifetime
+			}
+
+			leeway := m.leeway
+			if leeway > lifetime/2 {
+				leeway = lifetime / 2
+			}
+
+			m.token = resp.AccessToken
+			m.expiresAt = requestedAt.Add(lifetime)
+			m.refreshAt = m.expiresAt.Add(-leeway)
+		}
+	}
+	call.err = err
+	if m.inflight == call {
+		m.inflight = nil
+	}
+	m.mu.Unlock()
+
+	close(call.done)
+}
+
+// BusinessAccessToken returns a B2B access token from the client's token manager.
+// Clients that were not created with New request a fresh token on every call.
+func (c Client) BusinessAccessToken(ctx context.Context) (string, error) {
+	if c.tokens != nil {
+		return c.tokens.Token(ctx)
+	}
+
+	resp, err := c.GetBusinessAccessToken(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.AccessToken == "" {
+		return "", ErrEmptyAccessToken
+	}
+
+	return resp.AccessToken, nil
+}
+
+// InvalidateBusinessAccessToken drops the cached B2B access token, if any.
+func (c Client) InvalidateBusinessAccessToken() {
+	if c.tokens != nil {
+		c.tokens.Invalidate()
+	}
+}
+
+// rejectTokens drops the cached B2B token when respErr rejects it as the token of
+// header.
+func (c Client) rejectTokens(header RequestHeader, respErr *ResponseError) {
+	code := respErr.ResponseCode
+	if c.tokens == nil || code.HTTPCode() != http.StatusUnauthorized || !slices.Contains(rejectedTokenCaseCodes, code.CaseCode()) {
+		return
+	}
+
+	c.tokens.reject(strings.TrimPrefix(header.Authorization, "Bearer "))
+}
+
+// resolveB2BToken returns b2bToken unchanged when the caller supplied one, otherwise
+// it obtains one from the token manager.
+func (c Client) resolveB2BToken(ctx context.Context, b2bToken string) (string, error) {
+	if b2bToken != "" {
+		return b2bToken, nil
+	}
+
+	return c.BusinessAccessToken(ctx)
+}
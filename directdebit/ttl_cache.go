@@ -0,0 +1,83 @@
+package directdebit
+
+import (
+	"sync"
+	"time"
+)
+
+type ttlEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// ttlCache is the map behind the in-memory stores. Expired entries are evicted when
+// they are looked up and whenever a new entry is stored.
+type ttlCache[K comparable, V any] struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	entries map[K]ttlEntry[V]
+}
+
+func newTTLCache[K comparable, V any]() *ttlCache[K, V] {
+	return &ttlCache[K, V]{
+		now:     time.Now,
+		entries: map[K]ttlEntry[V]{},
+	}
+}
+
+func (c *ttlCache[K, V]) get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lookup(key)
+}
+
+// take returns the entry of key and removes it, so that only one of concurrent
+// callers gets it.
+func (c *ttlCache[K, V]) take(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	value, ok := c.lookup(key)
+	delete(c.entries, key)
+
+	return value, ok
+}
+
+func (c *ttlCache[K, V]) set(key K, value V, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for k, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+
+	c.entries[key] = ttlEntry[V]{value: value, expiresAt: now.Add(ttl)}
+}
+
+func (c *ttlCache[K, V]) delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+}
+
+// lookup must be called with mu held.
+func (c *ttlCache[K, V]) lookup(key K) (V, bool) {
+	var zero V
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return zero, false
+	}
+
+	if !c.now().Before(entry.expiresAt) {
+		delete(c.entries, key)
+		return zero, false
+	}
+
+	return entry.value, true
+}
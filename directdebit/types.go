@@ -1,7 +1,10 @@
 package directdebit
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"time"
 
 	"golang.org/x/exp/slices"
 
@@ -9,7 +12,7 @@ import (
 )
 
 var (
-	CardExpiredResponseCode = []string{
+	CardExpiredResponseCode = []ResponseCode{
 		"4033318",
 		"4033305",
 		"4033307",
@@ -23,24 +26,72 @@ var (
 		"500",
 	}
 
-	ClientSideErrorResponseCode = []string{
+	ClientSideErrorResponseCode = []ResponseCode{
 		"4033320", // validation or ayoconnect configuration error
 	}
 
-	CardLinkageTimeoutResponseCode = []string{
+	CardLinkageTimeoutResponseCode = []ResponseCode{
 		"5000000",
 	}
+
+	DebitNotCancellableResponseCode = []ResponseCode{
+		"4045700", // transaction already settled, refunded or cancelled
+		"4035700", // cancellation window has expired
+	}
+
+	DebitNotFoundResponseCode = []ResponseCode{
+		"4045701",
+	}
 )
 
 type Config struct {
-	ClientID        string
-	ClientSecret    string
-	MerchantID      string
-	RsaPrivateKey   string
+	ClientID     string
+	ClientSecret string
+	MerchantID   string
+	// RsaPrivateKey is a PEM encoded PKCS#1 or PKCS#8 RSA private key.
+	RsaPrivateKey string
+	// RsaPrivateKeyPassphrase decrypts RsaPrivateKey when it is encrypted.
+	RsaPrivateKeyPassphrase string
+	// Signer signs access token requests. When nil, RsaPrivateKey is parsed once
+	// in New and used instead.
+	Signer          Signer
 	EndpointBaseURL string
 	ChannelID       string
 	Logger          *slog.Logger
 	HTTPClient      *http.Client
+	// TokenRefreshLeeway is how long before expiry the cached B2B access token is
+	// refreshed, at most half of its lifetime. Defaults to DefaultTokenRefreshLeeway.
+	TokenRefreshLeeway time.Duration
+	// CustomerTokenStore keeps B2B2C access tokens between calls. Defaults to an
+	// in-memory store.
+	CustomerTokenStore CustomerTokenStore
+	// AyoconnectPublicKey is the PEM encoded RSA public key of Ayoconnect. When set,
+	// NotificationHandler verifies notifications with VerifyRSARequestSignature
+	// instead of the HMAC signature.
+	AyoconnectPublicKey string
+	// MaxClockSkew is the largest difference allowed between the X-TIMESTAMP of an
+	// incoming notification and the local clock. Defaults to DefaultMaxClockSkew.
+	MaxClockSkew time.Duration
+	// RetryPolicy retries failed requests. Requests are not retried when it is nil,
+	// use DefaultRetryPolicy for sensible defaults.
+	RetryPolicy *RetryPolicy
+	// ReconcilePolicy controls how SafeDebit polls DebitStatus after an ambiguous
+	// failure. Defaults to DefaultReconcilePolicy.
+	ReconcilePolicy *ReconcilePolicy
+	// Middlewares wrap every request sent by the client, the first one is the
+	// outermost. Retried requests pass through them once per attempt.
+	Middlewares []Middleware
+	// Metrics receives request, retry and token refresh measurements.
+	Metrics Metrics
+	// Redactor masks sensitive values in logged requests and responses. Defaults to
+	// DefaultRedactor.
+	Redactor *Redactor
+	// Debug logs a DebugDump of every attempt at debug level, with secrets masked
+	// by Redactor. The Logger must be enabled for debug level.
+	Debug bool
+	// DebugHook receives the DebugDump of every attempt, secrets included. It is
+	// meant to diagnose signature mismatches and must not be set in production.
+	DebugHook func(ctx context.Context, dump *DebugDump)
 }
 
 type SeamlessData struct {
@@ -63,16 +114,31 @@ type GetAuthCodeRequest struct {
 }
 
 type GetAuthCodeResponse struct {
-	ResponseCode    string `json:"responseCode"`
-	ResponseMessage string `json:"responseMessage"`
-	AuthCode        string `json:"authCode"`
-	State           string `json:"state"`
+	ResponseCode    ResponseCode `json:"responseCode"`
+	ResponseMessage string       `json:"responseMessage"`
+	AuthCode        string       `json:"authCode"`
+	State           string       `json:"state"`
 }
 
 type GetCardsRequest struct {
-	PartnerReferenceNo string
-	MerchantID         string
-	PublicUserID       string
+	PartnerReferenceNo string `json:"partnerReferenceNo"`
+	MerchantID         string `json:"merchantId"`
+	PublicUserID       string `json:"publicUserId"`
+}
+
+type Card struct {
+	MaskedCard   string `json:"maskedCard"`
+	BankCode     string `json:"bankCode"`
+	AccountToken string `json:"accountToken"`
+	TokenStatus  string `json:"tokenStatus"`
+}
+
+type GetCardListResponse struct {
+	ResponseCode       ResponseCode `json:"responseCode"`
+	ResponseMessage    string       `json:"responseMessage"`
+	PartnerReferenceNo string       `json:"partnerReferenceNo"`
+	UserInfo           UserInfo     `json:"userInfo"`
+	Cards              []Card       `json:"cards"`
 }
 
 type DebitTransactionRequest struct {
@@ -101,12 +167,12 @@ type GetCustomerAccessTokenRequest struct {
 }
 
 type GetAccessTokenResponse struct {
-	ResponseCode    string `json:"responseCode"`
-	ResponseMessage string `json:"responseMessage"`
-	TokenType       string `json:"tokenType"`
-	ResponseTime    string `json:"responseTime"`
-	AccessToken     string `json:"accessToken"`
-	ExpiredIn       int    `json:"expiresIn"`
+	ResponseCode    ResponseCode `json:"responseCode"`
+	ResponseMessage string       `json:"responseMessage"`
+	TokenType       string       `json:"tokenType"`
+	ResponseTime    string       `json:"responseTime"`
+	AccessToken     string       `json:"accessToken"`
+	ExpiredIn       int          `json:"expiresIn"`
 }
 
 type RequestHeader struct {
@@ -121,7 +187,10 @@ type RequestHeader struct {
 }
 
 type Client struct {
-	Config *Config
+	Config             *Config
+	signer             Signer
+	tokens             *TokenManager
+	customerTokenStore CustomerTokenStore
 }
 
 type AccountBindingRequest struct {
@@ -140,7 +209,7 @@ type AccountBindingAdditionalInfo struct {
 }
 
 type AccountBindingResponse struct {
-	ResponseCode       string                       `json:"responseCode"`
+	ResponseCode       ResponseCode                 `json:"responseCode"`
 	ResponseMessage    string                       `json:"responseMessage"`
 	PartnerReferenceNo string                       `json:"partnerReferenceNo"`
 	AccountToken       string                       `json:"accountToken"`
@@ -151,12 +220,19 @@ type AccountBindingResponse struct {
 }
 
 type DebitResponse struct {
-	ResponseCode       string              `json:"responseCode"`
+	ResponseCode       ResponseCode        `json:"responseCode"`
 	ResponseMessage    string              `json:"responseMessage"`
 	PartnerReferenceNo string              `json:"partnerReferenceNo"`
 	ReferenceNo        string              `json:"referenceNo"`
 	Amount             Amount              `json:"amount"`
 	AdditionalInfo     DebitAdditionalInfo `json:"additionalInfo"`
+	// LatestTransactionStatus is one of the TransactionStatus constants, it is only
+	// returned by DebitStatus and debit notifications.
+	LatestTransactionStatus string `json:"latestTransactionStatus,omitempty"`
+	TransactionStatusDesc   string `json:"transactionStatusDesc,omitempty"`
+	// WebRedirectURL is the page where the customer approves a debit that needs
+	// it, they are then sent back to the URLParam of the request.
+	WebRedirectURL string `json:"webRedirectUrl,omitempty"`
 }
 
 type DebitRequest struct {
@@ -200,7 +276,7 @@ type AccountUnbindRequestAdditionalInfo struct {
 }
 
 type AccountUnbindResponse struct {
-	ResponseCode       string                              `json:"responseCode"`
+	ResponseCode       ResponseCode                        `json:"responseCode"`
 	ResponseMessage    string                              `json:"responseMessage"`
 	PartnerReferenceNo string                              `json:"partnerReferenceNo"`
 	ReferenceNo        string                              `json:"referenceNo"`
@@ -212,30 +288,190 @@ type AccountUnbindResponseAdditionalInfo struct {
 	UnlinkOtpToken string `json:"unlinkOtpToken"`
 }
 
+type RefundRequest struct {
+	PartnerRefundNo            string                      `json:"partnerRefundNo"`
+	OriginalPartnerReferenceNo string                      `json:"originalPartnerReferenceNo"`
+	OriginalReferenceNo        string                      `json:"originalReferenceNo"`
+	MerchantID                 string                      `json:"merchantId"`
+	RefundAmount               Amount                      `json:"refundAmount"`
+	Reason                     string                      `json:"reason,omitempty"`
+	AdditionalInfo             RefundRequestAdditionalInfo `json:"additionalInfo"`
+}
+
+type RefundRequestAdditionalInfo struct {
+	PublicUserID string `json:"publicUserId"`
+	AccountToken string `json:"accountToken,omitempty"`
+}
+
+type RefundResponse struct {
+	ResponseCode               ResponseCode                 `json:"responseCode"`
+	ResponseMessage            string                       `json:"responseMessage"`
+	OriginalPartnerReferenceNo string                       `json:"originalPartnerReferenceNo"`
+	OriginalReferenceNo        string                       `json:"originalReferenceNo"`
+	PartnerRefundNo            string                       `json:"partnerRefundNo"`
+	RefundNo                   string                       `json:"refundNo"`
+	RefundAmount               Amount                       `json:"refundAmount"`
+	RefundTime                 string                       `json:"refundTime"`
+	AdditionalInfo             RefundResponseAdditionalInfo `json:"additionalInfo"`
+}
+
+type RefundResponseAdditionalInfo struct {
+	PublicUserID string `json:"publicUserId,omitempty"`
+	RefundResult string `json:"refundResult,omitempty"`
+}
+
+type CancelDebitRequest struct {
+	OriginalPartnerReferenceNo string                           `json:"originalPartnerReferenceNo"`
+	OriginalReferenceNo        string                           `json:"originalReferenceNo,omitempty"`
+	OriginalExternalID         string                           `json:"originalExternalId,omitempty"`
+	MerchantID                 string                           `json:"merchantId"`
+	Reason                     string                           `json:"reason,omitempty"`
+	Amount                     Amount                           `json:"amount"`
+	AdditionalInfo             CancelDebitRequestAdditionalInfo `json:"additionalInfo"`
+}
+
+type CancelDebitRequestAdditionalInfo struct {
+	PublicUserID string `json:"publicUserId"`
+}
+
+type CancelDebitResponse struct {
+	ResponseCode               ResponseCode                      `json:"responseCode"`
+	ResponseMessage            string                            `json:"responseMessage"`
+	OriginalPartnerReferenceNo string                            `json:"originalPartnerReferenceNo"`
+	OriginalReferenceNo        string                            `json:"originalReferenceNo"`
+	CancelTime                 string                            `json:"cancelTime"`
+	TransactionDate            string                            `json:"transactionDate"`
+	AdditionalInfo             CancelDebitResponseAdditionalInfo `json:"additionalInfo"`
+}
+
+type CancelDebitResponseAdditionalInfo struct {
+	PublicUserID  string `json:"publicUserId,omitempty"`
+	PaymentResult string `json:"paymentResult,omitempty"`
+}
+
+// DebitNotification is the payload Ayoconnect posts when the result of a debit is known.
+type DebitNotification struct {
+	DebitResponse
+	OriginalPartnerReferenceNo string `json:"originalPartnerReferenceNo"`
+	OriginalReferenceNo        string `json:"originalReferenceNo"`
+}
+
+type NotificationAcknowledgement struct {
+	ResponseCode    ResponseCode `json:"responseCode"`
+	ResponseMessage string       `json:"responseMessage"`
+}
+
+// Transaction statuses reported in LatestTransactionStatus.
+const (
+	TransactionStatusSuccess   = "00"
+	TransactionStatusInitiated = "01"
+	TransactionStatusPaying    = "02"
+	TransactionStatusPending   = "03"
+	TransactionStatusRefunded  = "04"
+	TransactionStatusCanceled  = "05"
+	TransactionStatusFailed    = "06"
+	TransactionStatusNotFound  = "07"
+)
+
+// OTP actions accepted by VerifyOTP.
+const (
+	OTPActionBinding   = "binding"
+	OTPActionUnbinding = "unbinding"
+	OTPActionPayment   = "payment"
+)
+
+type VerifyOTPRequest struct {
+	PartnerReferenceNo         string                         `json:"partnerReferenceNo"`
+	OriginalPartnerReferenceNo string                         `json:"originalPartnerReferenceNo,omitempty"`
+	OriginalReferenceNo        string                         `json:"originalReferenceNo"`
+	Action                     string                         `json:"action"`
+	MerchantID                 string                         `json:"merchantId"`
+	OTP                        string                         `json:"otp"`
+	AdditionalInfo             VerifyOTPRequestAdditionalInfo `json:"additionalInfo"`
+}
+
+type VerifyOTPRequestAdditionalInfo struct {
+	PublicUserID string `json:"publicUserId"`
+	AccountToken string `json:"accountToken,omitempty"`
+	BankCode     string `json:"bankCode,omitempty"`
+	OtpToken     string `json:"otpToken,omitempty"`
+}
+
+type VerifyOTPResponse struct {
+	ResponseCode        ResponseCode                    `json:"responseCode"`
+	ResponseMessage     string                          `json:"responseMessage"`
+	PartnerReferenceNo  string                          `json:"partnerReferenceNo"`
+	OriginalReferenceNo string                          `json:"originalReferenceNo"`
+	ReferenceNo         string                          `json:"referenceNo"`
+	AccountToken        string                          `json:"accountToken,omitempty"`
+	TokenStatus         string                          `json:"tokenStatus,omitempty"`
+	UserInfo            UserInfo                        `json:"userInfo"`
+	Amount              Amount                          `json:"amount"`
+	AdditionalInfo      VerifyOTPResponseAdditionalInfo `json:"additionalInfo"`
+}
+
+type VerifyOTPResponseAdditionalInfo struct {
+	MaskedCard    string `json:"maskedCard,omitempty"`
+	BankCode      string `json:"bankCode,omitempty"`
+	UnlinkResult  string `json:"unlinkResult,omitempty"`
+	PaymentResult string `json:"paymentResult,omitempty"`
+}
+
+// ResponseError is returned for every response with an unexpected HTTP status. The
+// SNAP fields are empty when the body is not a SNAP JSON response, e.g. an HTML page
+// returned by a gateway.
 type ResponseError struct {
-	ResponseCode        string `json:"responseCode"`
-	ResponseMessage     string `json:"responseMessage"`
-	ResponseDescription string `json:"responseDescription"`
-	StatusCode          int
+	ResponseCode        ResponseCode `json:"responseCode"`
+	ResponseMessage     string       `json:"responseMessage"`
+	ResponseDescription string       `json:"responseDescription"`
+	StatusCode          int          `json:"-"`
+	// Method and Endpoint identify the request that failed, Endpoint is the path
+	// without its query string.
+	Method   string `json:"-"`
+	Endpoint string `json:"-"`
+	// Header holds the response headers, with Config.Redactor applied.
+	Header http.Header `json:"-"`
+	// RequestID is the value of the first RequestIDHeaders found in Header.
+	RequestID string `json:"-"`
+	// Body is the response body with Config.Redactor applied, truncated to
+	// MaxErrorBodySize bytes.
+	Body string `json:"-"`
 }
 
 func (e *ResponseError) Error() string {
-	return e.ResponseMessage
+	if e.ResponseMessage != "" {
+		return e.ResponseMessage
+	}
+
+	return fmt.Sprintf("unexpected response from %s %s: %d %s", e.Method, e.Endpoint, e.StatusCode, http.StatusText(e.StatusCode))
 }
 
-func IsDebitCardDisabledError(responseCode string) bool {
-	return slices.Contains(CardExpiredResponseCode, responseCode)
+func IsDebitCardDisabledError[T ~string](responseCode T) bool {
+	return slices.Contains(CardExpiredResponseCode, ResponseCode(responseCode))
 }
 
-func IsClientSideError(responseCode string) bool {
-	if slices.Contains(ClientSideErrorResponseCode, responseCode) {
+func IsClientSideError[T ~string](responseCode T) bool {
+	if slices.Contains(ClientSideErrorResponseCode, ResponseCode(responseCode)) {
 		return true
 	}
-	httpCode := responseCode[0:3]
+	if len(responseCode) < 3 {
+		return false
+	}
+	httpCode := string(responseCode[0:3])
 
 	return slices.Contains(ClientSideErrorHTTPCode, httpCode)
 }
 
-func IsCardLinkageTimeoutError(responseCode string) bool {
-	return slices.Contains(CardLinkageTimeoutResponseCode, responseCode)
+func IsCardLinkageTimeoutError[T ~string](responseCode T) bool {
+	return slices.Contains(CardLinkageTimeoutResponseCode, ResponseCode(responseCode))
+}
+
+// IsDebitNotCancellableError reports whether CancelDebit was rejected because the
+// debit has already settled or can no longer be cancelled.
+func IsDebitNotCancellableError[T ~string](responseCode T) bool {
+	return slices.Contains(DebitNotCancellableResponseCode, ResponseCode(responseCode))
+}
+
+func IsDebitNotFoundError[T ~string](responseCode T) bool {
+	return slices.Contains(DebitNotFoundResponseCode, ResponseCode(responseCode))
 }
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
-	"time"
 )
 
 func (c Client) Unbind(
@@ -14,8 +13,11 @@ func (c Client) Unbind(
 	b2b2cToken,
 	externalID string,
 ) (*AccountUnbindResponse, error) {
+	b2bToken, err := c.resolveB2BToken(ctx, b2bToken)
+	if err != nil {
+		return nil, err
+	}
 	endpoint := UnbindEndpoint
-	timestamp := time.Now().Format(time.RFC3339)
 
 	if req.MerchantID == "" {
 		req.MerchantID = c.Config.MerchantID
@@ -25,10 +27,13 @@ func (c Client) Unbind(
 		return nil, err
 	}
 
-	signature := generateHmacSignature("POST", endpoint, b2bToken, string(body), timestamp, c.Config.ClientSecret)
-	headers := c.BuildHeader(timestamp, signature, b2bToken, b2b2cToken, externalID)
-
-	resp, err := c.Execute(ctx, http.MethodPost, endpoint, headers, body)
+	resp, err := c.send(ctx, &call{
+		operation: OperationUnbind,
+		method:    http.MethodPost,
+		path:      endpoint,
+		body:      body,
+		sign:      c.hmacHeaders(http.MethodPost, endpoint, body, b2bToken, b2b2cToken, externalID),
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -27,7 +27,12 @@ Z4Mar+3f+SFIElF+M+aD4AzzibGUJghJ03cbR6Pt
 		Logger:        slog.Default(),
 	}
 
-	client, _ := directdebit.New(cfg)
+	client, err := directdebit.New(cfg)
+	if err != nil {
+		slog.Error(err.Error())
+		return ""
+	}
+
 	resp, err := client.GetBusinessAccessToken(context.Background())
 	if err != nil {
 		slog.Error(err.Error())